@@ -2,6 +2,10 @@ package board
 
 import (
 	"fmt"
+	"io"
+	"iter"
+	"os"
+	"slices"
 
 	"github.com/erikbryant/magnets/common"
 )
@@ -53,31 +57,23 @@ func (c *Coord) Unpack() (int, int) {
 	return c.Row, c.Col
 }
 
-// Cells iterates over every cell in the layer, pushing the row/col to a channel.
+// Cells iterates over every cell in the layer, yielding the row/col.
 // If you provide a filter it will return only those cells matching those values.
-// Be careful with the filter as you can get non-deterministic behavior if you change
-// values in the layer while iterating over the layer.
-func (l *Board) Cells(r ...rune) <-chan Coord {
-	c := make(chan Coord, l.Width())
-
-	go func() {
-		defer close(c)
+// Each cell is checked against the filter as the iteration reaches it, so changes
+// made to the layer while iterating are seen by the cells not yet visited.
+func (l *Board) Cells(r ...rune) iter.Seq[Coord] {
+	return func(yield func(Coord) bool) {
 		for row := 0; row < l.Height(); row++ {
 			for col := 0; col < l.Width(); col++ {
-				if r == nil {
-					c <- Coord{Row: row, Col: col}
+				if r != nil && !slices.Contains(r, l.Get(row, col, false)) {
 					continue
 				}
-				for _, val := range r {
-					if l.Get(row, col, false) == val {
-						c <- Coord{Row: row, Col: col}
-					}
+				if !yield(Coord{Row: row, Col: col}) {
+					return
 				}
 			}
 		}
-	}()
-
-	return c
+	}
 }
 
 // Width returns the width of the layer.
@@ -160,6 +156,16 @@ func (l *Board) FloodFill() {
 	}
 }
 
+// Clone returns a copy of the board that does not share storage with it.
+// Copying a Board by value shares its cells, so use Clone to snapshot one.
+func (l *Board) Clone() Board {
+	c := Board{width: l.width, height: l.height, cells: make([][]rune, l.height)}
+	for row := range c.cells {
+		c.cells[row] = slices.Clone(l.cells[row])
+	}
+	return c
+}
+
 // Equal returns true if the two boards are identical, false otherwise.
 func (l *Board) Equal(l2 Board) bool {
 	if l.Height() != l2.Height() || l.Width() != l2.Width() {
@@ -178,9 +184,14 @@ func (l *Board) Equal(l2 Board) bool {
 
 // Print prints a representation of the board state to the console.
 func (l *Board) Print(name string, rowPos, rowNeg, colPos, colNeg []int) {
-	fmt.Printf("%s (%dx%d)\n", name, l.width, l.height)
+	l.Fprint(os.Stdout, name, rowPos, rowNeg, colPos, colNeg)
+}
+
+// Fprint writes a representation of the board state to w.
+func (l *Board) Fprint(w io.Writer, name string, rowPos, rowNeg, colPos, colNeg []int) {
+	fmt.Fprintf(w, "%s (%dx%d)\n", name, l.width, l.height)
 
-	fmt.Printf("     ")
+	fmt.Fprintf(w, "     ")
 	for i := 0; i < l.width; i++ {
 		count := 0
 		if len(colPos) > 0 {
@@ -188,15 +199,15 @@ func (l *Board) Print(name string, rowPos, rowNeg, colPos, colNeg []int) {
 		} else {
 			count = l.CountCol(i, common.Positive)
 		}
-		fmt.Printf("%1d", count)
+		fmt.Fprintf(w, "%1d", count)
 	}
-	fmt.Printf("\n")
+	fmt.Fprintf(w, "\n")
 
-	fmt.Printf("   + ")
+	fmt.Fprintf(w, "   + ")
 	for i := 0; i < l.width; i++ {
-		fmt.Printf("―")
+		fmt.Fprintf(w, "―")
 	}
-	fmt.Printf("\n")
+	fmt.Fprintf(w, "\n")
 
 	for row := 0; row < l.height; row++ {
 		count := 0
@@ -205,9 +216,9 @@ func (l *Board) Print(name string, rowPos, rowNeg, colPos, colNeg []int) {
 		} else {
 			count = l.CountRow(row, common.Positive)
 		}
-		fmt.Printf("%2d | ", count)
+		fmt.Fprintf(w, "%2d | ", count)
 		for _, cell := range l.cells[row] {
-			fmt.Printf("%c", cell)
+			fmt.Fprintf(w, "%c", cell)
 		}
 		count = 0
 		if len(rowNeg) > 0 {
@@ -215,16 +226,16 @@ func (l *Board) Print(name string, rowPos, rowNeg, colPos, colNeg []int) {
 		} else {
 			count = l.CountRow(row, common.Negative)
 		}
-		fmt.Printf(" | %2d\n", count)
+		fmt.Fprintf(w, " | %2d\n", count)
 	}
 
-	fmt.Printf("     ")
+	fmt.Fprintf(w, "     ")
 	for i := 0; i < l.width; i++ {
-		fmt.Printf("―")
+		fmt.Fprintf(w, "―")
 	}
-	fmt.Printf(" -\n")
+	fmt.Fprintf(w, " -\n")
 
-	fmt.Printf("     ")
+	fmt.Fprintf(w, "     ")
 	for i := 0; i < l.width; i++ {
 		count := 0
 		if len(colNeg) > 0 {
@@ -232,9 +243,9 @@ func (l *Board) Print(name string, rowPos, rowNeg, colPos, colNeg []int) {
 		} else {
 			count = l.CountCol(i, common.Negative)
 		}
-		fmt.Printf("%1d", count)
+		fmt.Fprintf(w, "%1d", count)
 	}
-	fmt.Printf("\n")
+	fmt.Fprintf(w, "\n")
 
-	fmt.Printf("\n")
+	fmt.Fprintf(w, "\n")
 }
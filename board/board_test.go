@@ -337,3 +337,31 @@ func TestEqual(t *testing.T) {
 		t.Errorf("ERROR: For l == l3 expected %t got %t", expected, answer)
 	}
 }
+
+func TestClone(t *testing.T) {
+	l := New(2, 3)
+	l.Set(0, 0, common.Positive, false)
+	l.Set(0, 1, common.Negative, false)
+
+	c := l.Clone()
+	if !c.Equal(l) {
+		t.Errorf("ERROR: Expected the clone to equal the original")
+	}
+
+	// Changes to one do not show in the other.
+	c.Set(1, 0, common.Neutral, false)
+	l.Set(2, 1, common.Wall, false)
+	if l.Get(1, 0, false) != common.Empty {
+		t.Errorf("ERROR: Changing the clone changed the original")
+	}
+	if c.Get(2, 1, false) != common.Empty {
+		t.Errorf("ERROR: Changing the original changed the clone")
+	}
+
+	// Whereas a copy by value shares the cells.
+	shared := l
+	shared.Set(1, 1, common.Neutral, false)
+	if l.Get(1, 1, false) != common.Neutral {
+		t.Errorf("ERROR: Expected a copy by value to share the cells")
+	}
+}
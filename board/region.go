@@ -0,0 +1,55 @@
+package board
+
+import (
+	"slices"
+
+	"github.com/erikbryant/magnets/common"
+)
+
+// Region is a set of orthogonally connected cells, in row-major order.
+type Region []Coord
+
+// Components returns the connected regions of cells whose value is one of r,
+// in the row-major order of their first cells. Cells with any other value,
+// and the edges of the board, are barriers between regions.
+func (l *Board) Components(r ...rune) []Region {
+	seen := New(l.width, l.height)
+	var regions []Region
+
+	for start := range l.Cells(r...) {
+		if seen.Get(start.Row, start.Col, false) != common.Empty {
+			continue
+		}
+
+		// Flood out from the first cell not yet in a region.
+		region := Region{start}
+		seen.Set(start.Row, start.Col, common.Marker, false)
+		for i := 0; i < len(region); i++ {
+			for _, mod := range Adjacents {
+				row, col := region[i].Row+mod.Row, region[i].Col+mod.Col
+				if !slices.Contains(r, l.Get(row, col, false)) || seen.Get(row, col, false) != common.Empty {
+					continue
+				}
+				seen.Set(row, col, common.Marker, false)
+				region = append(region, Coord{Row: row, Col: col})
+			}
+		}
+
+		slices.SortFunc(region, func(a, b Coord) int {
+			if a.Row != b.Row {
+				return a.Row - b.Row
+			}
+			return a.Col - b.Col
+		})
+		regions = append(regions, region)
+	}
+
+	return regions
+}
+
+// Parity returns 0 for the cells of a checkerboard colored like the top left
+// corner, and 1 for the others. Within a region of magnets, the cells of one
+// parity all have the same sign and the cells of the other the opposite sign.
+func (c Coord) Parity() int {
+	return (c.Row + c.Col) % 2
+}
@@ -0,0 +1,64 @@
+package board
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/erikbryant/magnets/common"
+)
+
+func TestComponents(t *testing.T) {
+	// +-#
+	// ##-
+	// +-+
+	l := fromRows("+-#", "##-", "+-+")
+
+	expected := []Region{
+		{{0, 0}, {0, 1}},
+		{{1, 2}, {2, 0}, {2, 1}, {2, 2}},
+	}
+	answer := l.Components(common.Positive, common.Negative)
+	if !slices.EqualFunc(answer, expected, slices.Equal) {
+		t.Errorf("ERROR: Expected %v got %v", expected, answer)
+	}
+
+	// The neutrals form two regions.
+	expected = []Region{
+		{{0, 2}},
+		{{1, 0}, {1, 1}},
+	}
+	answer = l.Components(common.Neutral)
+	if !slices.EqualFunc(answer, expected, slices.Equal) {
+		t.Errorf("ERROR: Expected %v got %v", expected, answer)
+	}
+
+	// With no barriers there is one region.
+	answer = l.Components(common.Positive, common.Negative, common.Neutral)
+	if len(answer) != 1 || len(answer[0]) != 9 {
+		t.Errorf("ERROR: Expected one region of 9 cells got %v", answer)
+	}
+
+	answer = l.Components(common.Wall)
+	if len(answer) != 0 {
+		t.Errorf("ERROR: Expected no regions got %v", answer)
+	}
+}
+
+func TestParity(t *testing.T) {
+	testCases := []struct {
+		c        Coord
+		expected int
+	}{
+		{Coord{0, 0}, 0},
+		{Coord{0, 1}, 1},
+		{Coord{1, 0}, 1},
+		{Coord{3, 5}, 0},
+	}
+
+	for _, testCase := range testCases {
+		answer := testCase.c.Parity()
+		if answer != testCase.expected {
+			t.Errorf("ERROR: For %v expected %d got %d", testCase.c, testCase.expected, answer)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package board
+
+// Transform is one of the eight symmetries of a rectangle.
+type Transform int
+
+// The transforms. Rotations are clockwise.
+const (
+	Identity Transform = iota
+	Rotate90
+	Rotate180
+	Rotate270
+	// MirrorHorizontal swaps left and right.
+	MirrorHorizontal
+	// MirrorVertical swaps top and bottom.
+	MirrorVertical
+	// Transpose swaps rows and columns, mirroring in the main diagonal.
+	Transpose
+	// AntiTranspose mirrors in the other diagonal.
+	AntiTranspose
+)
+
+// Transforms lists all of the transforms, starting with the identity.
+var Transforms = []Transform{Identity, Rotate90, Rotate180, Rotate270, MirrorHorizontal, MirrorVertical, Transpose, AntiTranspose}
+
+// String returns the name of the transform.
+func (t Transform) String() string {
+	switch t {
+	case Identity:
+		return "identity"
+	case Rotate90:
+		return "rotate90"
+	case Rotate180:
+		return "rotate180"
+	case Rotate270:
+		return "rotate270"
+	case MirrorHorizontal:
+		return "mirror-horizontal"
+	case MirrorVertical:
+		return "mirror-vertical"
+	case Transpose:
+		return "transpose"
+	case AntiTranspose:
+		return "anti-transpose"
+	}
+	return "unknown"
+}
+
+// Transposes returns true if the transform turns rows into columns.
+func (t Transform) Transposes() bool {
+	return t == Rotate90 || t == Rotate270 || t == Transpose || t == AntiTranspose
+}
+
+// Inverse returns the transform that undoes this one.
+func (t Transform) Inverse() Transform {
+	switch t {
+	case Rotate90:
+		return Rotate270
+	case Rotate270:
+		return Rotate90
+	}
+	return t
+}
+
+// Size returns the width and height of a widthxheight board once transformed.
+func (t Transform) Size(width, height int) (int, int) {
+	if t.Transposes() {
+		return height, width
+	}
+	return width, height
+}
+
+// Map returns where row,col on a widthxheight board ends up once transformed.
+func (t Transform) Map(row, col, width, height int) (int, int) {
+	switch t {
+	case Rotate90:
+		return col, height - 1 - row
+	case Rotate180:
+		return height - 1 - row, width - 1 - col
+	case Rotate270:
+		return width - 1 - col, row
+	case MirrorHorizontal:
+		return row, width - 1 - col
+	case MirrorVertical:
+		return height - 1 - row, col
+	case Transpose:
+		return col, row
+	case AntiTranspose:
+		return width - 1 - col, height - 1 - row
+	}
+	return row, col
+}
+
+// Transform returns a new board that is this one transformed.
+func (l *Board) Transform(t Transform) Board {
+	width, height := t.Size(l.width, l.height)
+	b := New(width, height)
+
+	for cell := range l.Cells() {
+		row, col := cell.Unpack()
+		r, c := t.Map(row, col, l.width, l.height)
+		b.cells[r][c] = l.cells[row][col]
+	}
+
+	return b
+}
@@ -0,0 +1,71 @@
+package board
+
+import (
+	"testing"
+)
+
+// fromRows makes a board from strings, one per row.
+func fromRows(rows ...string) Board {
+	l := New(len([]rune(rows[0])), len(rows))
+	for row, line := range rows {
+		for col, r := range []rune(line) {
+			l.Set(row, col, r, false)
+		}
+	}
+	return l
+}
+
+func TestTransform(t *testing.T) {
+	// abc
+	// def
+	l := fromRows("abc", "def")
+
+	testCases := []struct {
+		t        Transform
+		expected Board
+	}{
+		{Identity, fromRows("abc", "def")},
+		{Rotate90, fromRows("da", "eb", "fc")},
+		{Rotate180, fromRows("fed", "cba")},
+		{Rotate270, fromRows("cf", "be", "ad")},
+		{MirrorHorizontal, fromRows("cba", "fed")},
+		{MirrorVertical, fromRows("def", "abc")},
+		{Transpose, fromRows("ad", "be", "cf")},
+		{AntiTranspose, fromRows("fc", "eb", "da")},
+	}
+
+	for _, testCase := range testCases {
+		answer := l.Transform(testCase.t)
+		if !answer.Equal(testCase.expected) {
+			t.Errorf("ERROR: For %s expected %v got %v", testCase.t, testCase.expected.cells, answer.cells)
+		}
+
+		// The inverse undoes it.
+		back := answer.Transform(testCase.t.Inverse())
+		if !back.Equal(l) {
+			t.Errorf("ERROR: For %s the inverse %s gave %v", testCase.t, testCase.t.Inverse(), back.cells)
+		}
+
+		// It is a new board.
+		answer.Set(0, 0, 'x', false)
+		if l.Get(0, 0, false) != 'a' {
+			t.Errorf("ERROR: For %s the original board changed", testCase.t)
+		}
+	}
+
+	if len(Transforms) != 8 {
+		t.Errorf("ERROR: Expected 8 transforms got %d", len(Transforms))
+	}
+}
+
+func TestTransformSize(t *testing.T) {
+	for _, tr := range Transforms {
+		w, h := tr.Size(3, 2)
+		if tr.Transposes() && (w != 2 || h != 3) {
+			t.Errorf("ERROR: For %s expected 2x3 got %dx%d", tr, w, h)
+		}
+		if !tr.Transposes() && (w != 3 || h != 2) {
+			t.Errorf("ERROR: For %s expected 3x2 got %dx%d", tr, w, h)
+		}
+	}
+}
@@ -0,0 +1,708 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/erikbryant/magnets/board"
+	"github.com/erikbryant/magnets/common"
+	"github.com/erikbryant/magnets/magnets"
+	"github.com/erikbryant/magnets/render"
+	"github.com/erikbryant/magnets/sgt"
+	"github.com/erikbryant/magnets/solver"
+)
+
+var (
+	// errDone stops a generator once a command has all the games it wants.
+	errDone = errors.New("done")
+)
+
+// genFlags are the flags shared by the commands that generate games.
+type genFlags struct {
+	width   int
+	height  int
+	workers int
+	seed    int64
+	count   int
+}
+
+// register adds the generator flags to the flag set.
+func (g *genFlags) register(fs *flag.FlagSet, count int) {
+	fs.IntVar(&g.width, "width", 0, "width of each game (0 for random)")
+	fs.IntVar(&g.height, "height", 0, "height of each game (0 for random)")
+	fs.IntVar(&g.workers, "workers", 0, "number of parallel workers (0 for one per CPU)")
+	fs.Int64Var(&g.seed, "seed", 0, "random seed (0 for the current time)")
+	fs.IntVar(&g.count, "count", count, "number of games (0 for no limit)")
+}
+
+// options returns the generator options the flags describe.
+func (g genFlags) options() magnets.GenerateOptions {
+	seed := g.seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	return magnets.GenerateOptions{
+		Width:   g.width,
+		Height:  g.height,
+		Workers: g.workers,
+		Seed:    seed,
+		Count:   g.count,
+	}
+}
+
+// formats are the ways a game can be written out.
+var formats = []string{"serial", "solution", "code", "json", "svg", "svg-solution", "png", "png-solution", "ascii", "unicode", "ansi", "save", "print"}
+
+// writeGame writes the game in the given format.
+func writeGame(w io.Writer, game magnets.Game, format string) error {
+	switch format {
+	case "serial":
+		s, ok := game.Serialize()
+		if !ok {
+			return fmt.Errorf("could not serialize game")
+		}
+		_, err := fmt.Fprintln(w, s)
+		return err
+	case "solution":
+		s, ok := game.SerializeWithSolution()
+		if !ok {
+			return fmt.Errorf("could not serialize game with its solution")
+		}
+		_, err := fmt.Fprintln(w, s)
+		return err
+	case "code":
+		s, ok := game.Code()
+		if !ok {
+			return fmt.Errorf("could not encode game")
+		}
+		_, err := fmt.Fprintln(w, s)
+		return err
+	case "json":
+		j, err := json.Marshal(game)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(j))
+		return err
+	case "svg":
+		return render.SVG(w, game, render.Options{Show: render.ShowPuzzle})
+	case "svg-solution":
+		return render.SVG(w, game, render.Options{Show: render.ShowSolution})
+	case "png":
+		return render.PNG(w, game, render.Options{Show: render.ShowPuzzle})
+	case "png-solution":
+		return render.PNG(w, game, render.Options{Show: render.ShowSolution})
+	case "ascii":
+		return game.Fprint(w, magnets.ASCII)
+	case "unicode":
+		return game.Fprint(w, magnets.Unicode)
+	case "ansi":
+		return game.Fprint(w, magnets.ANSI)
+	case "save":
+		return sgt.WriteSave(w, game, sgt.DefaultParams)
+	case "print":
+		game.Print()
+		return nil
+	}
+
+	return fmt.Errorf("unknown format %q (want one of %s)", format, strings.Join(formats, ", "))
+}
+
+// readIDs calls each with every puzzle ID given on the command line or, if
+// there are none, every line of stdin that is not blank or a '//' comment.
+func readIDs(args []string, each func(id string) error) error {
+	if len(args) > 0 {
+		for _, id := range args {
+			err := each(id)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		id := strings.TrimSpace(scanner.Text())
+		if len(id) == 0 || strings.HasPrefix(id, "//") {
+			continue
+		}
+		err := each(id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// loadID reads a puzzle ID, which may be our serial form or any of Simon
+// Tatham's game IDs. Our serials and codes are read first, as they allow
+// sizes, such as 1x2, that Simon Tatham's IDs do not.
+func loadID(ctx context.Context, id string) (magnets.Game, bool) {
+	game, ok := magnets.Deserialize(id)
+	if ok {
+		return game, true
+	}
+	game, _, err := sgt.ParseID(ctx, id)
+	return game, err == nil
+}
+
+// lookupSolver returns the named solver, or an error listing the choices.
+func lookupSolver(name string) (solver.Solver, error) {
+	s, ok := solver.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown solver %q (want one of %s)", name, strings.Join(solver.Names(), ", "))
+	}
+	return s, nil
+}
+
+// generateCmd creates games and writes them out.
+func generateCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	var gen genFlags
+	gen.register(fs, 1)
+	format := fs.String("format", "serial", "output format: "+strings.Join(formats, ", "))
+	solvable := fs.Bool("solvable", false, "only output games the constraint-based solver can solve")
+	fs.Parse(args)
+
+	err := magnets.Generate(ctx, gen.options(), func(game magnets.Game) error {
+		if *solvable {
+			grade, err := solver.Grade(ctx, game)
+			if err != nil {
+				return err
+			}
+			if grade == solver.Unsolved {
+				return nil
+			}
+		}
+		return writeGame(os.Stdout, game, *format)
+	})
+
+	return err
+}
+
+// encodeGrid returns the board as one string, rows separated by '/', walls
+// shown as '*' and undecided cells shown as '.'.
+func encodeGrid(b board.Board) string {
+	var sb strings.Builder
+	for row := 0; row < b.Height(); row++ {
+		if row > 0 {
+			sb.WriteRune('/')
+		}
+		for col := 0; col < b.Width(); col++ {
+			r := b.Get(row, col, false)
+			switch r {
+			case common.Empty:
+				r = '.'
+			case common.Wall:
+				r = '*'
+			}
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// rulesUsed returns the distinct rules in the trace, in order of first use,
+// separated by commas. It returns "-" if there are none.
+func rulesUsed(trace []string) string {
+	var rules []string
+	seen := map[string]bool{}
+	for _, rule := range trace {
+		if !seen[rule] {
+			seen[rule] = true
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return "-"
+	}
+	return strings.Join(rules, ",")
+}
+
+// solutionCount returns the number of solutions the game has, stopping at 2.
+func solutionCount(ctx context.Context, game magnets.Game) (int, error) {
+	seen := 0
+	return game.EnumerateSolutions(ctx, func(board.Board) bool {
+		seen++
+		return seen < 2
+	})
+}
+
+// solveCmd solves each game and writes one line per game: the puzzle ID, the
+// status (solved, stuck, invalid, multiple or, if the puzzle carries its own
+// solution and the solver's answer differs from it, wrong), the solution grid,
+// the elapsed time and the rules used. A game with no solution is invalid.
+// Lines are written as soon as each game is done, so the output can be piped
+// or diffed.
+func solveCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("solve", flag.ExitOnError)
+	name := fs.String("solver", "cbs", "solver to use: "+strings.Join(solver.Names(), ", "))
+	elapsed := fs.Bool("elapsed", true, "include the elapsed time (turn off to diff outputs)")
+	fs.Parse(args)
+
+	s, err := lookupSolver(*name)
+	if err != nil {
+		return err
+	}
+
+	return readIDs(fs.Args(), func(id string) error {
+		game, ok := loadID(ctx, id)
+		if !ok || !game.Valid() {
+			fmt.Println(id, "invalid - - -")
+			return nil
+		}
+
+		result, err := s.Solve(ctx, game)
+		if err != nil {
+			return err
+		}
+
+		status := result.Status.String()
+		switch result.Status {
+		case solver.Solved:
+			answer, ok := game.Solution()
+			if ok && !answer.Equal(result.Solution) {
+				status = "wrong"
+			}
+		case solver.Contradiction:
+			status = "invalid"
+		case solver.Stuck:
+			// Stuck on a game with no solution or more than one is
+			// not the solver's fault.
+			n, err := solutionCount(ctx, game)
+			if err != nil {
+				return err
+			}
+			switch {
+			case n == 0:
+				status = "invalid"
+			case n > 1:
+				status = "multiple"
+			}
+		}
+
+		took := "-"
+		if *elapsed {
+			took = result.Stats.Elapsed.String()
+		}
+
+		fmt.Println(id, status, encodeGrid(result.Solution), took, rulesUsed(result.Stats.Trace))
+		return nil
+	})
+}
+
+// countCmd counts the solutions to each game.
+func countCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("count", flag.ExitOnError)
+	method := fs.String("method", "dlx", "how to count: brute, dlx")
+	fs.Parse(args)
+
+	return readIDs(fs.Args(), func(id string) error {
+		game, ok := loadID(ctx, id)
+		if !ok {
+			fmt.Println(id, "invalid")
+			return nil
+		}
+
+		var solutions int
+		var err error
+		visit := func(board.Board) bool { return true }
+		switch *method {
+		case "brute":
+			solutions, err = game.BacktrackSolutions(ctx, visit)
+		case "dlx":
+			solutions, err = game.EnumerateSolutions(ctx, visit)
+		default:
+			return fmt.Errorf("unknown method %q (want brute or dlx)", *method)
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(id, solutions)
+		return nil
+	})
+}
+
+// verifyCmd checks that each game is valid and has exactly one solution.
+func verifyCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	fs.Parse(args)
+
+	failed := 0
+
+	err := readIDs(fs.Args(), func(id string) error {
+		game, ok := loadID(ctx, id)
+		if !ok || !game.Valid() {
+			fmt.Println(id, "invalid")
+			failed++
+			return nil
+		}
+
+		solutions, err := solutionCount(ctx, game)
+		if err != nil {
+			return err
+		}
+		switch {
+		case solutions == 0:
+			fmt.Println(id, "no solution")
+			failed++
+			return nil
+		case solutions > 1:
+			fmt.Println(id, "multiple solutions")
+			failed++
+			return nil
+		}
+
+		fmt.Println(id, "ok")
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d games failed verification", failed)
+	}
+
+	return nil
+}
+
+// gradeCmd rates how hard each game is.
+func gradeCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("grade", flag.ExitOnError)
+	fs.Parse(args)
+
+	return readIDs(fs.Args(), func(id string) error {
+		game, ok := loadID(ctx, id)
+		if !ok {
+			fmt.Println(id, "invalid")
+			return nil
+		}
+
+		grade, err := solver.Grade(ctx, game)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(id, grade)
+		return nil
+	})
+}
+
+// corpusCmd creates games and tries to solve them. The ones it can solve it
+// writes to one file and the ones it cannot solve it writes to another file.
+// 'corpus dedupe' instead removes equivalent puzzles from existing files.
+func corpusCmd(ctx context.Context, args []string) error {
+	if len(args) > 0 && args[0] == "dedupe" {
+		return dedupeCmd(args[1:])
+	}
+
+	fs := flag.NewFlagSet("corpus", flag.ExitOnError)
+	var gen genFlags
+	gen.register(fs, 0)
+	target := fs.Int("solved", 1000000, "stop after this many games have been solved")
+	solvedFile := fs.String("solved-file", "solved", "file to append the solved games to")
+	unsolvedFile := fs.String("unsolved-file", "unsolved", "file to append the unsolved games to")
+	fs.Parse(args)
+
+	games := 0
+	solved := 0
+
+	err := magnets.Generate(ctx, gen.options(), func(game magnets.Game) error {
+		games++
+
+		err := solver.SolveContext(ctx, &game)
+		if err != nil {
+			return err
+		}
+
+		serial, ok := game.Serialize()
+		if !ok {
+			game.Print()
+			return fmt.Errorf("could not serialize game")
+		}
+
+		if game.Solved() {
+			solved++
+			appendLine(*solvedFile, serial)
+		} else {
+			appendLine(*unsolvedFile, serial)
+		}
+
+		if games%10000 == 0 {
+			pctSolved := 100.0 * float64(solved) / float64(games)
+			fmt.Printf("Played: %d Solved: %d (%.3f%%)\n", games, solved, pctSolved)
+		}
+
+		if solved >= *target {
+			return errDone
+		}
+		return nil
+	})
+	if err == errDone {
+		return nil
+	}
+
+	return err
+}
+
+// dedupeCmd removes puzzles that are the same as an earlier one up to
+// rotation, reflection and swapping + and -. Blank lines and '//' comments are
+// kept. With -w the files are rewritten, otherwise the result goes to stdout.
+func dedupeCmd(args []string) error {
+	fs := flag.NewFlagSet("corpus dedupe", flag.ExitOnError)
+	write := fs.Bool("w", false, "rewrite the files in place")
+	fs.Parse(args)
+
+	files := fs.Args()
+	if len(files) == 0 {
+		if *write {
+			return fmt.Errorf("-w needs files")
+		}
+		files = []string{"-"}
+	}
+
+	seen := map[string]bool{}
+	removed := 0
+
+	for _, file := range files {
+		var text []byte
+		var err error
+		if file == "-" {
+			text, err = io.ReadAll(os.Stdin)
+		} else {
+			text, err = os.ReadFile(file)
+		}
+		if err != nil {
+			return err
+		}
+
+		var kept strings.Builder
+		for _, line := range strings.Split(strings.TrimSuffix(string(text), "\n"), "\n") {
+			id := strings.TrimSpace(line)
+			if id != "" && !strings.HasPrefix(id, "//") {
+				game, ok := magnets.Deserialize(id)
+				if !ok {
+					return fmt.Errorf("%s: invalid puzzle %q", file, id)
+				}
+				_, canonical, ok := game.Canonical()
+				if !ok {
+					return fmt.Errorf("%s: invalid puzzle %q", file, id)
+				}
+				if seen[canonical] {
+					removed++
+					continue
+				}
+				seen[canonical] = true
+			}
+			kept.WriteString(line + "\n")
+		}
+
+		if *write {
+			err = os.WriteFile(file, []byte(kept.String()), 0644)
+		} else {
+			_, err = io.WriteString(os.Stdout, kept.String())
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Removed %d duplicates\n", removed)
+	return nil
+}
+
+// stressCmd creates random boards and tries to solve them until it is
+// interrupted or has played enough games. At intervals it prints success/fail
+// statistics and the average number of regions of magnets in each game.
+func stressCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("stress", flag.ExitOnError)
+	var gen genFlags
+	gen.register(fs, 0)
+	report := fs.Int("report", 10000, "print statistics every this many games")
+	fs.Parse(args)
+
+	start := time.Now()
+	games := 0
+	solved := 0
+	regions := 0
+
+	err := magnets.Generate(ctx, gen.options(), func(game magnets.Game) error {
+		games++
+		regions += len(game.Regions())
+
+		err := solver.SolveContext(ctx, &game)
+		if err != nil {
+			return err
+		}
+
+		if game.Solved() {
+			solved++
+		}
+
+		if games%*report == 0 {
+			pctSolved := 100.0 * float64(solved) / float64(games)
+			avgRegions := float64(regions) / float64(games)
+			fmt.Printf("Played: %d Solved: %d (%.3f%%) Regions: %.2f per game\n", games, solved, pctSolved, avgRegions)
+		}
+		return nil
+	})
+
+	fmt.Println("Elapsed time:", time.Since(start))
+
+	if err == context.Canceled {
+		return nil
+	}
+	return err
+}
+
+// benchCmd runs every game through each solver and reports how long each
+// solver took and how it did.
+func benchCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	names := fs.String("solvers", strings.Join(solver.Names(), ","), "comma-separated solvers to time")
+	fs.Parse(args)
+
+	var games []magnets.Game
+	err := readIDs(fs.Args(), func(id string) error {
+		game, ok := loadID(ctx, id)
+		if !ok {
+			return fmt.Errorf("could not deserialize %s", id)
+		}
+		games = append(games, game)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range strings.Split(*names, ",") {
+		s, err := lookupSolver(name)
+		if err != nil {
+			return err
+		}
+
+		statuses := map[solver.Status]int{}
+		start := time.Now()
+		for _, game := range games {
+			result, err := s.Solve(ctx, game)
+			if err != nil {
+				return err
+			}
+			statuses[result.Status]++
+		}
+		elapsed := time.Since(start)
+
+		fmt.Printf("%-8s %12s  solved: %d stuck: %d contradiction: %d\n", s.Name(), elapsed, statuses[solver.Solved], statuses[solver.Stuck], statuses[solver.Contradiction])
+	}
+
+	return nil
+}
+
+// bookCmd writes a printable LaTeX book of the games, with their difficulty
+// and an answer key.
+func bookCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("book", flag.ExitOnError)
+	title := fs.String("title", "Magnets", "title of the book")
+	perPage := fs.Int("per-page", 4, "number of puzzles on each page")
+	fs.Parse(args)
+
+	dlx, err := lookupSolver("dlx")
+	if err != nil {
+		return err
+	}
+
+	var entries []render.BookEntry
+	err = readIDs(fs.Args(), func(id string) error {
+		game, ok := loadID(ctx, id)
+		if !ok {
+			return fmt.Errorf("could not deserialize %s", id)
+		}
+
+		grade, err := solver.Grade(ctx, game)
+		if err != nil {
+			return err
+		}
+
+		entry := render.BookEntry{
+			ID:         id,
+			Difficulty: grade.String(),
+			Game:       game,
+		}
+
+		if _, ok := game.Solution(); !ok {
+			result, err := dlx.Solve(ctx, game)
+			if err != nil {
+				return err
+			}
+			if result.Status != solver.Solved {
+				return fmt.Errorf("%s does not have exactly one solution", id)
+			}
+			entry.Solution = result.Solution
+		}
+
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return render.Book(os.Stdout, entries, render.BookOptions{Title: *title, PerPage: *perPage})
+}
+
+// parseCmd reads puzzles drawn as text, one per file or one from stdin, and
+// writes them out in the given format.
+func parseCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("parse", flag.ExitOnError)
+	format := fs.String("format", "serial", "output format: "+strings.Join(formats, ", "))
+	fs.Parse(args)
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	for _, file := range files {
+		var text []byte
+		var err error
+		if file == "-" {
+			text, err = io.ReadAll(os.Stdin)
+		} else {
+			text, err = os.ReadFile(file)
+		}
+		if err != nil {
+			return err
+		}
+
+		var game magnets.Game
+		if strings.HasPrefix(string(text), "SAVEFILE") {
+			game, _, err = sgt.ReadSave(strings.NewReader(string(text)))
+		} else {
+			game, err = magnets.Parse(string(text))
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+
+		err = writeGame(os.Stdout, game, *format)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
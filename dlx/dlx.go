@@ -0,0 +1,293 @@
+package dlx
+
+// This package implements Knuth's Algorithm X using Dancing Links, generalized
+// so that each primary item carries a multiplicity (the number of chosen options
+// that must contain it) and so that secondary items may be covered at most once.
+//
+// The matrix is a set of doubly-linked lists. Each item has a header node and a
+// vertical list of the option nodes that contain it. Hiding an option unlinks
+// its nodes from their item lists; unhiding relinks them in reverse order. All
+// of the bookkeeping is undone on the way back out of the search, so the matrix
+// is unchanged after a search completes.
+//
+// Branching is binary: pick the primary item with the least slack (options
+// remaining minus count still needed), take its first remaining option, and
+// either include that option or exclude it. This enumerates each solution
+// exactly once, even for items with multiplicity greater than one.
+
+import (
+	"context"
+)
+
+// Matrix is a generalized exact cover problem.
+type Matrix struct {
+	// Item list. Index 0 is the root. Only primary items with a non-zero
+	// need are linked into the list.
+	left  []int
+	right []int
+
+	// Node arrays. Nodes 0..items are the item headers.
+	up   []int
+	down []int
+	top  []int
+
+	// Per-item state.
+	length    []int
+	need      []int
+	secondary []bool
+
+	// Per-option node ranges, and the option each node belongs to.
+	optStart []int
+	optEnd   []int
+	nodeOpt  []int
+
+	chosen []int
+}
+
+// New creates an empty matrix.
+func New() *Matrix {
+	m := &Matrix{}
+
+	// The root node.
+	m.left = []int{0}
+	m.right = []int{0}
+	m.up = []int{0}
+	m.down = []int{0}
+	m.top = []int{0}
+	m.length = []int{0}
+	m.need = []int{0}
+	m.secondary = []bool{false}
+	m.nodeOpt = []int{-1}
+
+	return m
+}
+
+// addItem creates a new item header and returns its id.
+func (m *Matrix) addItem(count int, secondary bool) int {
+	if len(m.optStart) > 0 {
+		panic("dlx: items must be added before options")
+	}
+
+	i := len(m.top)
+
+	m.up = append(m.up, i)
+	m.down = append(m.down, i)
+	m.top = append(m.top, i)
+	m.length = append(m.length, 0)
+	m.need = append(m.need, count)
+	m.secondary = append(m.secondary, secondary)
+	m.nodeOpt = append(m.nodeOpt, -1)
+
+	// Link it into the item list, at the end.
+	m.left = append(m.left, m.left[0])
+	m.right = append(m.right, 0)
+	m.right[m.left[0]] = i
+	m.left[0] = i
+
+	if secondary {
+		// Secondary items are never chosen for branching.
+		m.right[m.left[i]] = m.right[i]
+		m.left[m.right[i]] = m.left[i]
+	}
+
+	return i
+}
+
+// AddPrimary adds an item that must be contained in exactly count of the
+// chosen options. It returns the item's id.
+func (m *Matrix) AddPrimary(count int) int {
+	return m.addItem(count, false)
+}
+
+// AddSecondary adds an item that may be contained in at most one of the
+// chosen options. It returns the item's id.
+func (m *Matrix) AddSecondary() int {
+	return m.addItem(1, true)
+}
+
+// AddOption adds an option made up of the given items. It returns the
+// option's id. Options are numbered from zero in the order they are added.
+func (m *Matrix) AddOption(items ...int) int {
+	opt := len(m.optStart)
+	m.optStart = append(m.optStart, len(m.top))
+
+	for _, i := range items {
+		n := len(m.top)
+		m.top = append(m.top, i)
+		m.nodeOpt = append(m.nodeOpt, opt)
+
+		// Insert at the bottom of the item's list.
+		m.up = append(m.up, m.up[i])
+		m.down = append(m.down, i)
+		m.down[m.up[i]] = n
+		m.up[i] = n
+		m.length[i]++
+	}
+
+	m.optEnd = append(m.optEnd, len(m.top))
+
+	return opt
+}
+
+// hideNode unlinks a single node from its item list.
+func (m *Matrix) hideNode(n int) {
+	m.down[m.up[n]] = m.down[n]
+	m.up[m.down[n]] = m.up[n]
+	m.length[m.top[n]]--
+}
+
+// unhideNode relinks a single node into its item list.
+func (m *Matrix) unhideNode(n int) {
+	m.down[m.up[n]] = n
+	m.up[m.down[n]] = n
+	m.length[m.top[n]]++
+}
+
+// hideOption unlinks every node of the option, except skip, from its item list.
+func (m *Matrix) hideOption(opt, skip int) {
+	for n := m.optStart[opt]; n < m.optEnd[opt]; n++ {
+		if n != skip {
+			m.hideNode(n)
+		}
+	}
+}
+
+// unhideOption reverses hideOption.
+func (m *Matrix) unhideOption(opt, skip int) {
+	for n := m.optEnd[opt] - 1; n >= m.optStart[opt]; n-- {
+		if n != skip {
+			m.unhideNode(n)
+		}
+	}
+}
+
+// cover removes a satisfied item from the item list and hides every other
+// option that contains it.
+func (m *Matrix) cover(i int) {
+	if !m.secondary[i] {
+		m.right[m.left[i]] = m.right[i]
+		m.left[m.right[i]] = m.left[i]
+	}
+	for n := m.down[i]; n != i; n = m.down[n] {
+		m.hideOption(m.nodeOpt[n], n)
+	}
+}
+
+// uncover reverses cover.
+func (m *Matrix) uncover(i int) {
+	for n := m.up[i]; n != i; n = m.up[n] {
+		m.unhideOption(m.nodeOpt[n], n)
+	}
+	if !m.secondary[i] {
+		m.right[m.left[i]] = i
+		m.left[m.right[i]] = i
+	}
+}
+
+// include adds the option to the partial solution.
+func (m *Matrix) include(opt int) {
+	m.chosen = append(m.chosen, opt)
+	m.hideOption(opt, -1)
+	for n := m.optStart[opt]; n < m.optEnd[opt]; n++ {
+		i := m.top[n]
+		m.need[i]--
+		if m.need[i] == 0 {
+			m.cover(i)
+		}
+	}
+}
+
+// exclude reverses include.
+func (m *Matrix) exclude(opt int) {
+	for n := m.optEnd[opt] - 1; n >= m.optStart[opt]; n-- {
+		i := m.top[n]
+		if m.need[i] == 0 {
+			m.uncover(i)
+		}
+		m.need[i]++
+	}
+	m.unhideOption(opt, -1)
+	m.chosen = m.chosen[:len(m.chosen)-1]
+}
+
+// choose returns the primary item with the least slack, or -1 if some item
+// can no longer be satisfied.
+func (m *Matrix) choose() int {
+	best := -1
+	bestSlack := 0
+
+	for i := m.right[0]; i != 0; i = m.right[i] {
+		slack := m.length[i] - m.need[i]
+		if slack < 0 {
+			return -1
+		}
+		if best == -1 || slack < bestSlack {
+			best = i
+			bestSlack = slack
+		}
+	}
+
+	return best
+}
+
+// search recursively enumerates the solutions. It returns false if the
+// visitor asked to stop or the context was cancelled.
+func (m *Matrix) search(ctx context.Context, visit func([]int) bool) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if m.right[0] == 0 {
+		return visit(m.chosen)
+	}
+
+	i := m.choose()
+	if i == -1 || m.down[i] == i {
+		return true
+	}
+
+	opt := m.nodeOpt[m.down[i]]
+
+	// Branch one: the option is part of the solution.
+	m.include(opt)
+	keepGoing := m.search(ctx, visit)
+	m.exclude(opt)
+	if !keepGoing {
+		return false
+	}
+
+	// Branch two: the option is not part of the solution.
+	m.hideOption(opt, -1)
+	keepGoing = m.search(ctx, visit)
+	m.unhideOption(opt, -1)
+
+	return keepGoing
+}
+
+// Search enumerates every solution, calling visit with the ids of the chosen
+// options. The slice is reused between calls, so copy it if you need to keep
+// it. If visit returns false the search stops. Search returns the number of
+// solutions visited, and the context's error if it was cancelled.
+func (m *Matrix) Search(ctx context.Context, visit func([]int) bool) (int, error) {
+	count := 0
+
+	// Items that start out needing nothing are already satisfied.
+	var satisfied []int
+	for i := 1; i < len(m.need); i++ {
+		if m.need[i] == 0 {
+			m.cover(i)
+			satisfied = append(satisfied, i)
+		}
+	}
+
+	m.search(ctx, func(options []int) bool {
+		count++
+		return visit(options)
+	})
+
+	for j := len(satisfied) - 1; j >= 0; j-- {
+		m.uncover(satisfied[j])
+	}
+
+	return count, ctx.Err()
+}
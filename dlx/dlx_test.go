@@ -0,0 +1,123 @@
+package dlx
+
+import (
+	"context"
+	"sort"
+	"testing"
+)
+
+func TestSearchExactCover(t *testing.T) {
+	// Knuth's example from "Dancing Links". The only solution is
+	// options 0, 3, and 4.
+	m := New()
+	items := make([]int, 7)
+	for i := range items {
+		items[i] = m.AddPrimary(1)
+	}
+	m.AddOption(items[2], items[4])
+	m.AddOption(items[0], items[3], items[6])
+	m.AddOption(items[1], items[2], items[5])
+	m.AddOption(items[0], items[3], items[5])
+	m.AddOption(items[1], items[6])
+	m.AddOption(items[3], items[4], items[6])
+
+	var solutions [][]int
+	count, _ := m.Search(context.Background(), func(options []int) bool {
+		solution := append([]int{}, options...)
+		sort.Ints(solution)
+		solutions = append(solutions, solution)
+		return true
+	})
+
+	if count != 1 {
+		t.Fatalf("ERROR: Expected 1 solution, got %d", count)
+	}
+	expected := []int{0, 3, 4}
+	for i := range expected {
+		if solutions[0][i] != expected[i] {
+			t.Errorf("ERROR: Expected %v, got %v", expected, solutions[0])
+		}
+	}
+}
+
+func TestSearchMultiplicity(t *testing.T) {
+	testCases := []struct {
+		count    int
+		options  int
+		expected int
+	}{
+		{0, 3, 1},
+		{1, 3, 3},
+		{2, 4, 6},
+		{3, 3, 1},
+		{4, 3, 0},
+	}
+
+	for _, testCase := range testCases {
+		m := New()
+		item := m.AddPrimary(testCase.count)
+		for i := 0; i < testCase.options; i++ {
+			m.AddOption(item)
+		}
+		answer, _ := m.Search(context.Background(), func([]int) bool { return true })
+		if answer != testCase.expected {
+			t.Errorf("ERROR: For %d of %d expected %d got %d", testCase.count, testCase.options, testCase.expected, answer)
+		}
+	}
+}
+
+func TestSearchSecondary(t *testing.T) {
+	// Two slots, each filled by one of two options. The options for
+	// different slots that share a secondary item cannot both be chosen.
+	m := New()
+	a := m.AddPrimary(1)
+	b := m.AddPrimary(1)
+	s := m.AddSecondary()
+	m.AddOption(a, s)
+	m.AddOption(a)
+	m.AddOption(b, s)
+	m.AddOption(b)
+
+	answer, _ := m.Search(context.Background(), func([]int) bool { return true })
+	if answer != 3 {
+		t.Errorf("ERROR: Expected 3 got %d", answer)
+	}
+
+	// The matrix is restored after a search, so it can be searched again.
+	answer, _ = m.Search(context.Background(), func([]int) bool { return true })
+	if answer != 3 {
+		t.Errorf("ERROR: Expected 3 on second search, got %d", answer)
+	}
+}
+
+func TestSearchStop(t *testing.T) {
+	m := New()
+	item := m.AddPrimary(1)
+	for i := 0; i < 5; i++ {
+		m.AddOption(item)
+	}
+
+	answer, _ := m.Search(context.Background(), func([]int) bool { return false })
+	if answer != 1 {
+		t.Errorf("ERROR: Expected search to stop after 1, got %d", answer)
+	}
+}
+
+func TestSearchCancel(t *testing.T) {
+	m := New()
+	item := m.AddPrimary(1)
+	for i := 0; i < 5; i++ {
+		m.AddOption(item)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	answer, err := m.Search(ctx, func([]int) bool { return true })
+	if err != context.Canceled {
+		t.Errorf("ERROR: Expected %v, got %v", context.Canceled, err)
+	}
+	if answer != 0 {
+		t.Errorf("ERROR: Expected no solutions, got %d", answer)
+	}
+}
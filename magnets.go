@@ -1,17 +1,47 @@
 package main
 
+// The magnets command generates, solves and checks games of magnets.
+//
+// Usage:
+//
+//	magnets <command> [flags] [puzzle IDs...]
+//
+// Commands that take puzzle IDs read them from the command line or, if there
+// are none there, one per line from stdin. A puzzle ID is our serial form,
+// our shorter code, or one of Simon Tatham's game IDs, such as 6x5dt#12345.
+// Run 'magnets <command> -h' for the flags each command takes.
+
 import (
+	"context"
 	"fmt"
-	"math/rand"
 	"os"
-	"time"
+	"os/signal"
+)
 
-	"github.com/erikbryant/magnets/magnets"
-	"github.com/erikbryant/magnets/solver"
+// command is a single subcommand of the CLI.
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, args []string) error
+}
+
+var (
+	commands = []command{
+		{"generate", "create games that have exactly one solution", generateCmd},
+		{"solve", "solve games", solveCmd},
+		{"count", "count the solutions to games", countCmd},
+		{"verify", "check that games are valid and have exactly one solution", verifyCmd},
+		{"grade", "rate how hard games are", gradeCmd},
+		{"corpus", "build a corpus of solved and unsolved games; 'corpus dedupe' removes equivalent puzzles", corpusCmd},
+		{"stress", "generate and solve games, reporting how many get solved", stressCmd},
+		{"bench", "time each solver on the same games", benchCmd},
+		{"book", "write a LaTeX puzzle book with an answer key", bookCmd},
+		{"parse", "read puzzles drawn as text or save files and write them out", parseCmd},
+	}
 )
 
-// append write the given content to the end of the given file.
-func append(file, content string) {
+// appendLine writes the given content to the end of the given file.
+func appendLine(file, content string) {
 	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		fmt.Println(err)
@@ -27,117 +57,37 @@ func append(file, content string) {
 	}
 }
 
-// createCorups creates games and tries to solve them. The ones it can solve it writes to
-// one file and the ones it cannot solve it writes to another file.
-func createCorpus() {
-	games := 0
-
-	for solved := 0; solved < 1000000; {
-		game := magnets.New(rand.Intn(15)+2, rand.Intn(15)+2)
-		games++
-
-		solver.Solve(game)
-
-		serial, ok := game.Serialize()
-		if !ok {
-			fmt.Println("Could not serialize game")
-			game.Print()
-			return
-		}
-
-		if game.Solved() {
-			solved++
-			append("solved", serial)
-		} else {
-			append("unsolved", serial)
-		}
-
-		if games%10000 == 0 {
-			pctSolved := 100.0 * float64(solved) / float64(games)
-			fmt.Printf("Played: %d Solved: %d (%.3f%%)\n", games, solved, pctSolved)
-		}
-	}
-}
-
-// stressTest loops forever, creating random boards and trying to solve them. At intervals
-// it prints success/fail statistics.
-func stressTest() {
-	games := 0
-	solved := 0
-
-	for {
-		game := magnets.New(rand.Intn(15)+2, rand.Intn(15)+2)
-		games++
-
-		solver.Solve(game)
-
-		if game.Solved() {
-			solved++
-		}
-
-		if games%10000 == 0 {
-			pctSolved := 100.0 * float64(solved) / float64(games)
-			fmt.Printf("Played: %d Solved: %d (%.3f%%)\n", games, solved, pctSolved)
-		}
+// usage prints the list of commands.
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: magnets <command> [flags] [puzzle IDs...]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.usage)
 	}
 }
 
-// deserializer takes a game in serial form and tries to solve it.
-func deserializer(s string) {
-	game, ok := magnets.Deserialize(s)
-	if !ok {
-		fmt.Println("Could not deserialize!", s)
-		return
-	}
-
-	solutions := game.CountSolutions(0, 0)
-	if solutions != 1 {
-		fmt.Println("Solutions:", solutions)
-		return
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
 	}
 
-	solver.Solve(game)
+	// Stop cleanly on ^C.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	if game.Solved() {
-		fmt.Println("Solved!", s)
-	} else {
-		fmt.Println("\nCould not solve!", s)
-		game.Print()
-	}
-}
-
-// findSolvableGame loops forever trying random boards until it can solve one.
-func findSolvableGame(width, height int) {
-	for {
-		game := magnets.New(width, height)
-		solver.Solve(game)
-		if !game.Solved() {
+	for _, c := range commands {
+		if c.name != os.Args[1] {
 			continue
 		}
-		s, _ := game.Serialize()
-		fmt.Printf("\"%s\",\n", s)
-		break
+		err := c.run(ctx, os.Args[2:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "magnets:", err)
+			os.Exit(1)
+		}
+		return
 	}
-}
-
-func main() {
-	start := time.Now()
-
-	// FAIL
-	// deserializer("14x4:22211111221122,5457,22121021221122,5466,TTLRTLRLRLRTLRBBLRBLRTTTTBTTTTTLRTTBBBBTBBBBBLRBBLRLRBLR")
-	// deserializer("4x4:1212,2022,1212,1212,TLRTBTTBTBBTBLRB")
-
-	deserializer("3x4:212,1202,122,2111,TTTBBBLRTLRB")
-	deserializer("10x2:0011101110,42,0011110110,33,TTTLRLRLRTBBBLRLRLRB")
-	deserializer("2x3:12,111,21,111,LRLRLR")
-	deserializer("4x12:6465,222221222220,6555,222222122211,TTTTBBBBTLRTBTTBTBBTBTTBTBBTBTTBTBBTBTTBTBBTBLRB")
-	deserializer("2x4:12,0111,21,0111,LRLRLRLR")
-	deserializer("2x5:12,00111,21,00111,TTBBLRTTBB")
-	deserializer("2x6:13,101011,22,100111,LRLRTTBBTTBB")
-	deserializer("2x6:13,101101,22,101110,LRLRLRLRTTBB")
-
-	// createCorpus()
-	stressTest()
 
-	fmt.Println("Elapsed time:", time.Since(start))
+	fmt.Fprintf(os.Stderr, "magnets: unknown command %q\n\n", os.Args[1])
+	usage()
+	os.Exit(2)
 }
@@ -1,21 +1,24 @@
 package magnets
 
 import (
+	"context"
+
+	"github.com/erikbryant/magnets/board"
 	"github.com/erikbryant/magnets/common"
 )
 
 // exceedsColLimits returns true if this row has exceeded the
 // legal positive/negative count for this column, false otherwise.
 func (game *Game) exceedsColLimits(col int) bool {
-	if game.Guess.CountCol(col, common.Positive) > game.CountCol(col, common.Positive) {
+	if count := game.CountCol(col, common.Positive); count >= 0 && game.Guess.CountCol(col, common.Positive) > count {
 		return true
 	}
 
-	if game.Guess.CountCol(col, common.Negative) > game.CountCol(col, common.Negative) {
+	if count := game.CountCol(col, common.Negative); count >= 0 && game.Guess.CountCol(col, common.Negative) > count {
 		return true
 	}
 
-	if game.Guess.CountCol(col, common.Neutral)+game.Guess.CountCol(col, common.Wall) > game.CountCol(col, common.Neutral) {
+	if count := game.CountCol(col, common.Neutral); count >= 0 && game.Guess.CountCol(col, common.Neutral)+game.Guess.CountCol(col, common.Wall) > count {
 		return true
 	}
 
@@ -25,15 +28,15 @@ func (game *Game) exceedsColLimits(col int) bool {
 // exceedsRowLimits returns true if this row has exceeded the
 // legal positive/negative count for this row, false otherwise.
 func (game *Game) exceedsRowLimits(row int) bool {
-	if game.Guess.CountRow(row, common.Positive) > game.CountRow(row, common.Positive) {
+	if count := game.CountRow(row, common.Positive); count >= 0 && game.Guess.CountRow(row, common.Positive) > count {
 		return true
 	}
 
-	if game.Guess.CountRow(row, common.Negative) > game.CountRow(row, common.Negative) {
+	if count := game.CountRow(row, common.Negative); count >= 0 && game.Guess.CountRow(row, common.Negative) > count {
 		return true
 	}
 
-	if game.Guess.CountRow(row, common.Neutral)+game.Guess.CountRow(row, common.Wall) > game.CountRow(row, common.Neutral) {
+	if count := game.CountRow(row, common.Neutral); count >= 0 && game.Guess.CountRow(row, common.Neutral)+game.Guess.CountRow(row, common.Wall) > count {
 		return true
 	}
 
@@ -81,10 +84,16 @@ func (game *Game) setCell(row, col int, r rune) bool {
 	return true
 }
 
-// CountSolutions returns the total number of valid solutions for the given game.
-func (game *Game) CountSolutions(row, col int) int {
+// backtrack visits each valid solution reachable from row, col. The solution
+// is in the Guess board while visit runs. It returns the number of solutions
+// visited and false if visit asked to stop or the context was cancelled.
+func (game *Game) backtrack(ctx context.Context, row, col int, visit func() bool) (int, bool) {
 	solutions := 0
 
+	if ctx.Err() != nil {
+		return 0, false
+	}
+
 	for {
 		if col >= game.Guess.Width() {
 			col = 0
@@ -92,10 +101,10 @@ func (game *Game) CountSolutions(row, col int) int {
 		}
 		if row >= game.Guess.Height() {
 			if game.Valid() && game.Solved() {
-				solutions++
+				return 1, visit()
 			}
 
-			return solutions
+			return 0, true
 		}
 
 		if game.frames.Get(row, col, false) == common.Up || game.frames.Get(row, col, false) == common.Left {
@@ -104,24 +113,58 @@ func (game *Game) CountSolutions(row, col int) int {
 		col++
 	}
 
-	if game.setCell(row, col, common.Positive) {
-		solutions += game.CountSolutions(row, col+1)
-	}
-
-	if game.setCell(row, col, common.Negative) {
-		solutions += game.CountSolutions(row, col+1)
-	}
-
-	if game.setCell(row, col, common.Neutral) {
-		solutions += game.CountSolutions(row, col+1)
+	for _, r := range []rune{common.Positive, common.Negative, common.Neutral} {
+		if game.setCell(row, col, r) {
+			found, keepGoing := game.backtrack(ctx, row, col+1, visit)
+			solutions += found
+			if !keepGoing {
+				game.blankCell(row, col)
+				return solutions, false
+			}
+		}
 	}
 
 	game.blankCell(row, col)
 
+	return solutions, true
+}
+
+// CountSolutions returns the total number of valid solutions for the given game.
+func (game *Game) CountSolutions(row, col int) int {
+	solutions, _ := game.backtrack(context.Background(), row, col, func() bool { return true })
 	return solutions
 }
 
+// CountSolutionsContext returns the total number of valid solutions for the
+// given game, or the context's error if it was cancelled first.
+func (game *Game) CountSolutionsContext(ctx context.Context) (int, error) {
+	solutions, _ := game.backtrack(ctx, 0, 0, func() bool { return true })
+	return solutions, ctx.Err()
+}
+
+// BacktrackSolutions finds every solution to the game by brute force, calling
+// visit with each one. If visit returns false the search stops. It returns the
+// number of solutions visited, and the context's error if it was cancelled.
+// The Guess board is left empty.
+func (game *Game) BacktrackSolutions(ctx context.Context, visit func(board.Board) bool) (int, error) {
+	solutions, _ := game.backtrack(ctx, 0, 0, func() bool {
+		solution := board.New(game.Guess.Width(), game.Guess.Height())
+		for cell := range game.Guess.Cells() {
+			row, col := cell.Unpack()
+			solution.Set(row, col, game.Guess.Get(row, col, false), false)
+		}
+		return visit(solution)
+	})
+	return solutions, ctx.Err()
+}
+
 // singleSolution returns true if there is only one solution for the game, false otherwise.
-func (game *Game) singleSolution() bool {
-	return game.CountSolutions(0, 0) == 1
+// It stops looking once it finds a second solution.
+func (game *Game) singleSolution(ctx context.Context) (bool, error) {
+	solutions := 0
+	game.backtrack(ctx, 0, 0, func() bool {
+		solutions++
+		return solutions < 2
+	})
+	return solutions == 1, ctx.Err()
 }
@@ -1,6 +1,7 @@
 package magnets
 
 import (
+	"context"
 	"testing"
 )
 
@@ -19,6 +20,9 @@ func TestCountSolutions(t *testing.T) {
 		{"3x2:111,21,111,12,TTTBBB", 1},
 		{"5x2:11011,22,11011,22,LRTLRLRBLR", 4},
 
+		// Some of the counts are unknown.
+		{"5x5:.2..1,3..1.,.2..2,2..2.,LRLRTTLRTBBT*BTTBLRBBLRLR", 1},
+
 		// Came from the iPhone. Guaranteed to have only one solution.
 		{"4x5:3222,22122,2322,22122,LRTTTTBBBBLRTTTTBBBB", 1},
 		{"4x5:3201,11211,2301,11121,LRTTTTBBBBTTTTBBBBLR", 1},
@@ -74,9 +78,29 @@ func TestSingleSolution(t *testing.T) {
 			t.Errorf("ERROR: failed to deserialize %s", testCase.game)
 		}
 
-		answer := game.singleSolution()
+		answer, _ := game.singleSolution(context.Background())
 		if answer != testCase.expected {
 			t.Errorf("ERROR: for %s expected %t got %t", testCase.game, testCase.expected, answer)
 		}
 	}
 }
+
+func TestCountSolutionsContext(t *testing.T) {
+	game, ok := Deserialize("2x2:11,11,11,11,TTBB")
+	if !ok {
+		t.Fatalf("ERROR: failed to deserialize")
+	}
+
+	answer, err := game.CountSolutionsContext(context.Background())
+	if err != nil || answer != 2 {
+		t.Errorf("ERROR: expected 2 solutions and no error, got %d %v", answer, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = game.CountSolutionsContext(ctx)
+	if err != context.Canceled {
+		t.Errorf("ERROR: expected %v, got %v", context.Canceled, err)
+	}
+}
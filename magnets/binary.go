@@ -0,0 +1,328 @@
+package magnets
+
+// A compact binary encoding of a game, and a URL-safe text form of it (a
+// "code") for share links and QR codes. The binary form is:
+//
+// version byte (1)
+// uvarint width
+// uvarint height
+// flags byte; bit 0 set if the solution follows the frames, bit 1 set if
+//   some counts are unknown
+// then a stream of bits, packed most significant bit first:
+// if flag bit 1 is set, one bit per count, in serial order (col positive,
+//   row positive, col negative, row negative), set if the count is known
+// each known count in the same order, as a varint in groups of 4 bits: 3
+//   bits of the count, least significant first, and a high bit set if more
+//   groups follow
+// the frames, reading across then down and skipping the cells already
+//   covered by a domino, 2 bits per cell: 00 for a wall, 01 for a
+//   horizontal domino and 10 for a vertical one
+// if flag bit 0 is set, the solution, 2 bits per domino in the same order as
+//   the serial form: 00 for neutral, 01 for + and 10 for - at the top/left end
+//
+// The 3x3 example from serialize.go is 12 bytes, and its code is
+//
+// AQMDACARAhIBEWhA
+//
+// The code is the binary form in unpadded base64url. Deserialize reads a code
+// as well as the serial form.
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/erikbryant/magnets/common"
+)
+
+const binaryVersion = 1
+
+// bitWriter packs values into bytes, most significant bit first.
+type bitWriter struct {
+	bytes []byte
+	n     int
+}
+
+// write appends the low bits of v.
+func (bw *bitWriter) write(v uint, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		if bw.n%8 == 0 {
+			bw.bytes = append(bw.bytes, 0)
+		}
+		if v&(1<<i) != 0 {
+			bw.bytes[len(bw.bytes)-1] |= 0x80 >> (bw.n % 8)
+		}
+		bw.n++
+	}
+}
+
+// writeVarint appends v in groups of 4 bits.
+func (bw *bitWriter) writeVarint(v uint) {
+	for v > 7 {
+		bw.write(8|v&7, 4)
+		v >>= 3
+	}
+	bw.write(v, 4)
+}
+
+// bitReader unpacks values written by a bitWriter.
+type bitReader struct {
+	bytes []byte
+	n     int
+}
+
+// read returns the next bits, and false if there are not enough of them.
+func (br *bitReader) read(bits int) (uint, bool) {
+	var v uint
+	for range bits {
+		if br.n >= 8*len(br.bytes) {
+			return 0, false
+		}
+		v <<= 1
+		if br.bytes[br.n/8]&(0x80>>(br.n%8)) != 0 {
+			v |= 1
+		}
+		br.n++
+	}
+	return v, true
+}
+
+// readVarint returns the next varint, and false if it is cut short or too
+// long.
+func (br *bitReader) readVarint() (uint, bool) {
+	var v uint
+	for shift := 0; shift < 30; shift += 3 {
+		group, ok := br.read(4)
+		if !ok {
+			return 0, false
+		}
+		v |= (group & 7) << shift
+		if group&8 == 0 {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// counts returns the game's counts in serial order.
+func (game *Game) counts() []*[]int {
+	return []*[]int{&game.colPos, &game.rowPos, &game.colNeg, &game.rowNeg}
+}
+
+// MarshalBinary returns the binary form of the game. The solution is included
+// if the game knows it.
+func (game *Game) MarshalBinary() ([]byte, error) {
+	if !game.Valid() {
+		return nil, errors.New("invalid game")
+	}
+
+	b := []byte{binaryVersion}
+	b = binary.AppendUvarint(b, uint64(game.grid.Width()))
+	b = binary.AppendUvarint(b, uint64(game.grid.Height()))
+
+	solution, hasSolution := game.Solution()
+	unknown := false
+	for _, counts := range game.counts() {
+		for _, count := range *counts {
+			unknown = unknown || count < 0
+		}
+	}
+
+	var flags byte
+	if hasSolution {
+		flags |= 1
+	}
+	if unknown {
+		flags |= 2
+	}
+	b = append(b, flags)
+
+	var bits bitWriter
+	if unknown {
+		for _, counts := range game.counts() {
+			for _, count := range *counts {
+				if count < 0 {
+					bits.write(0, 1)
+				} else {
+					bits.write(1, 1)
+				}
+			}
+		}
+	}
+	for _, counts := range game.counts() {
+		for _, count := range *counts {
+			if count >= 0 {
+				bits.writeVarint(uint(count))
+			}
+		}
+	}
+
+	for cell := range game.frames.Cells(common.Wall, common.Left, common.Up) {
+		row, col := cell.Unpack()
+		switch game.frames.Get(row, col, false) {
+		case common.Wall:
+			bits.write(0, 2)
+		case common.Left:
+			bits.write(1, 2)
+		case common.Up:
+			bits.write(2, 2)
+		}
+	}
+	if hasSolution {
+		for frame := range game.Frames() {
+			row, col := frame.Unpack()
+			switch solution.Get(row, col, false) {
+			case common.Neutral:
+				bits.write(0, 2)
+			case common.Positive:
+				bits.write(1, 2)
+			case common.Negative:
+				bits.write(2, 2)
+			}
+		}
+	}
+
+	return append(b, bits.bytes...), nil
+}
+
+// UnmarshalBinary reads the binary form of a game.
+func (game *Game) UnmarshalBinary(b []byte) error {
+	if len(b) == 0 || b[0] != binaryVersion {
+		return errors.New("unknown binary version")
+	}
+	b = b[1:]
+
+	var size [2]int
+	for i := range size {
+		v, n := binary.Uvarint(b)
+		if n <= 0 || v < 1 || v > 1<<16 {
+			return errors.New("bad size")
+		}
+		size[i] = int(v)
+		b = b[n:]
+	}
+	width, height := size[0], size[1]
+	// Every two cells take at least two bits, so a short input cannot
+	// claim a huge board.
+	if width*height > 8*len(b) {
+		return errors.New("size is larger than the data")
+	}
+	g := makeGame(width, height)
+
+	if len(b) == 0 || b[0] > 3 {
+		return errors.New("bad flags")
+	}
+	hasSolution := b[0]&1 != 0
+	unknown := b[0]&2 != 0
+	b = b[1:]
+
+	bits := bitReader{bytes: b}
+	for _, counts := range g.counts() {
+		for i := range *counts {
+			if !unknown {
+				continue
+			}
+			known, ok := bits.read(1)
+			if !ok {
+				return errors.New("short counts")
+			}
+			if known == 0 {
+				(*counts)[i] = -1
+			}
+		}
+	}
+	for _, counts := range g.counts() {
+		for i := range *counts {
+			if (*counts)[i] < 0 {
+				continue
+			}
+			v, ok := bits.readVarint()
+			if !ok {
+				return errors.New("bad count")
+			}
+			(*counts)[i] = int(v)
+		}
+	}
+
+	for row := 0; row < height; row++ {
+		for col := 0; col < width; col++ {
+			if g.frames.Get(row, col, false) != common.Empty {
+				continue
+			}
+			v, ok := bits.read(2)
+			if !ok {
+				return errors.New("short frames")
+			}
+			switch {
+			case v == 0:
+				g.frames.Set(row, col, common.Wall, false)
+				g.grid.Set(row, col, common.Wall, false)
+				g.Guess.Set(row, col, common.Wall, false)
+			case v == 1 && col+1 < width && g.frames.Get(row, col+1, false) == common.Empty:
+				g.frames.Set(row, col, common.Left, false)
+				g.frames.Set(row, col+1, common.Right, false)
+			case v == 2 && row+1 < height:
+				g.frames.Set(row, col, common.Up, false)
+				g.frames.Set(row+1, col, common.Down, false)
+			default:
+				return fmt.Errorf("bad frame at %d, %d", row, col)
+			}
+		}
+	}
+
+	if hasSolution {
+		var solution []byte
+		for range g.Frames() {
+			v, ok := bits.read(2)
+			if !ok || v > 2 {
+				return errors.New("bad solution")
+			}
+			solution = append(solution, "#+-"[v])
+		}
+		if !g.loadSolution(string(solution)) {
+			return errors.New("solution does not solve the game")
+		}
+	}
+
+	// The last byte is padded with zeros, and nothing follows it.
+	if len(b) != (bits.n+7)/8 {
+		return errors.New("trailing data")
+	}
+	if pad := (8 - bits.n%8) % 8; pad > 0 {
+		if v, _ := bits.read(pad); v != 0 {
+			return errors.New("bad padding")
+		}
+	}
+
+	if !g.Valid() {
+		return errors.New("invalid game")
+	}
+
+	*game = g
+	return nil
+}
+
+// Code returns the game's binary form as unpadded base64url.
+func (game *Game) Code() (string, bool) {
+	b, err := game.MarshalBinary()
+	if err != nil {
+		return "", false
+	}
+	return base64.RawURLEncoding.EncodeToString(b), true
+}
+
+// decodeCode reads a game from its code.
+func decodeCode(s string) (Game, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return makeGame(0, 0), false
+	}
+	var game Game
+	err = game.UnmarshalBinary(b)
+	if err != nil {
+		return makeGame(0, 0), false
+	}
+	game.serial = s
+	return game, true
+}
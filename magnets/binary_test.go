@@ -0,0 +1,107 @@
+package magnets
+
+import (
+	"encoding/base64"
+	"math"
+	"testing"
+)
+
+func TestCode(t *testing.T) {
+	testCases := []struct {
+		serial   string
+		expected string
+	}{
+		{"3x3:201,102,120,111,LRTT*BBLR", "AQMDACARAhIBEWhA"},
+		{"3x3:201,102,120,111,LRTT*BBLR,+#--", "AQMDASARAhIBEWhSgA"},
+		{"3x3:2..,102,...,111,LRTT*BBLR", "AQMDApxyECERaEA"},
+	}
+
+	for _, testCase := range testCases {
+		game, ok := Deserialize(testCase.serial)
+		if !ok {
+			t.Errorf("ERROR: Unable to deserialize %s", testCase.serial)
+			continue
+		}
+		code, ok := game.Code()
+		if !ok {
+			t.Errorf("ERROR: Unable to encode %s", testCase.serial)
+			continue
+		}
+		if code != testCase.expected {
+			t.Errorf("ERROR: For %s expected %s got %s", testCase.serial, testCase.expected, code)
+		}
+
+		game2, ok := Deserialize(code)
+		if !ok {
+			t.Errorf("ERROR: Unable to deserialize %s", code)
+			continue
+		}
+		if !game2.frames.Equal(game.frames) || !game2.grid.Equal(game.grid) {
+			t.Errorf("ERROR: %s did not round trip through %s", testCase.serial, code)
+		}
+	}
+}
+
+func TestCodeErrors(t *testing.T) {
+	game, _ := Deserialize("3x3:201,102,120,111,LRTT*BBLR,+#--")
+	b, _ := game.MarshalBinary()
+
+	// edit returns a copy of b changed by f.
+	edit := func(f func(b []byte) []byte) string {
+		c := append([]byte{}, b...)
+		return base64.RawURLEncoding.EncodeToString(f(c))
+	}
+
+	testCases := []string{
+		"",
+		"not base64!",
+		edit(func(b []byte) []byte { b[0] = 2; return b }),
+		edit(func(b []byte) []byte { b[1] = 0; return b }),
+		edit(func(b []byte) []byte { b[1] = 100; return b }),
+		edit(func(b []byte) []byte { b[3] = 4; return b }),
+		edit(func(b []byte) []byte { return b[:len(b)-1] }),
+		edit(func(b []byte) []byte { return append(b, 0) }),
+		// The padding bits are not zero.
+		edit(func(b []byte) []byte { b[len(b)-1] |= 1; return b }),
+		// The solution is wrong.
+		edit(func(b []byte) []byte { b[len(b)-1] ^= 0x40; return b }),
+		// The counts are cut short.
+		edit(func(b []byte) []byte { return b[:5] }),
+	}
+
+	for _, code := range testCases {
+		_, ok := Deserialize(code)
+		if ok {
+			t.Errorf("ERROR: Expected failure deserializing %q", code)
+		}
+	}
+}
+
+func TestCodeCorpus(t *testing.T) {
+	serials, codes := 0, 0
+	for _, game := range loadGames(t, "../solver/testcases_solve.txt", math.MaxInt) {
+		serial, _ := game.Serialize()
+		code, ok := game.Code()
+		if !ok {
+			t.Errorf("ERROR: Unable to encode %s", serial)
+			continue
+		}
+		serials += len(serial)
+		codes += len(code)
+		game2, ok := Deserialize(code)
+		if !ok {
+			t.Errorf("ERROR: Unable to deserialize %s", code)
+			continue
+		}
+		serial2, _ := game2.Serialize()
+		if serial2 != serial {
+			t.Errorf("ERROR: Expected %s got %s", serial, serial2)
+		}
+	}
+
+	// Tiny games are no shorter, but across the corpus codes should be far
+	// shorter.
+	if codes*2 > serials {
+		t.Errorf("ERROR: Codes total %d characters, serials %d", codes, serials)
+	}
+}
@@ -0,0 +1,41 @@
+package magnets
+
+import (
+	"github.com/erikbryant/magnets/board"
+)
+
+// Two games are the same puzzle if one is a rotation or reflection of the
+// other, possibly with + and - swapped (and so the counts swapped too). The
+// canonical form of a game is the one of these 16 variants with the smallest
+// serial, so equivalent games have the same canonical form.
+
+// Canonical returns the canonical form of the game and its serial. It returns
+// false if the game is not valid. The solution and guess are carried along.
+func (game *Game) Canonical() (Game, string, bool) {
+	var best Game
+	bestSerial := ""
+	for _, t := range board.Transforms {
+		g := game.Transform(t)
+		for _, variant := range []Game{g, g.SwapPolarity()} {
+			serial, ok := variant.Serialize()
+			if !ok {
+				return variant, "", false
+			}
+			if bestSerial == "" || serial < bestSerial {
+				best, bestSerial = variant, serial
+			}
+		}
+	}
+	return best, bestSerial, true
+}
+
+// Equivalent returns true if the two games are the same puzzle, up to
+// rotation, reflection and swapping the polarity.
+func (game *Game) Equivalent(other Game) bool {
+	_, a, ok := game.Canonical()
+	if !ok {
+		return false
+	}
+	_, b, ok := other.Canonical()
+	return ok && a == b
+}
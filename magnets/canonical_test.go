@@ -0,0 +1,50 @@
+package magnets
+
+import (
+	"testing"
+
+	"github.com/erikbryant/magnets/board"
+)
+
+func TestCanonical(t *testing.T) {
+	for _, serial := range []string{
+		"3x3:201,102,120,111,LRTT*BBLR,+#--",
+		"5x4:12222,2322,12222,3222,TLRTTBTTBBTBBLRBLRLR,-++--+#+--",
+		"3x3:2..,102,...,111,LRTT*BBLR",
+	} {
+		game, ok := Deserialize(serial)
+		if !ok {
+			t.Errorf("ERROR: Unable to deserialize %s", serial)
+			continue
+		}
+		_, expected, ok := game.Canonical()
+		if !ok {
+			t.Errorf("ERROR: No canonical form for %s", serial)
+			continue
+		}
+
+		// Every variant has the same canonical form, and its solution
+		// still solves it.
+		for _, tr := range board.Transforms {
+			g := game.Transform(tr)
+			for _, g := range []Game{g, g.SwapPolarity()} {
+				c, answer, _ := g.Canonical()
+				if answer != expected {
+					t.Errorf("ERROR: For %s %s expected %s got %s", serial, tr, expected, answer)
+				}
+				if _, ok := c.Solution(); ok && !c.solves(c.grid) {
+					t.Errorf("ERROR: For %s %s the solution does not solve %s", serial, tr, answer)
+				}
+				if !game.Equivalent(g) {
+					t.Errorf("ERROR: For %s %s expected the games to be equivalent", serial, tr)
+				}
+			}
+		}
+	}
+
+	a, _ := Deserialize("3x3:201,102,120,111,LRTT*BBLR")
+	b, _ := Deserialize("3x3:2..,102,...,111,LRTT*BBLR")
+	if a.Equivalent(b) {
+		t.Errorf("ERROR: Expected the games not to be equivalent")
+	}
+}
@@ -0,0 +1,173 @@
+package magnets
+
+import (
+	"context"
+
+	"github.com/erikbryant/magnets/board"
+	"github.com/erikbryant/magnets/common"
+	"github.com/erikbryant/magnets/dlx"
+)
+
+// The game as a generalized exact cover problem.
+//
+// Items:
+//   - One primary item per domino. Each domino is in exactly one state.
+//   - One primary item per known row/col positive/negative/neutral count, with
+//     a multiplicity equal to that count. The neutral counts follow from the
+//     others, but having them lets the search prune much earlier.
+//   - One secondary item per pair of adjacent cells (that are not the two
+//     ends of the same domino) and polarity. Two like poles cannot touch.
+//
+// Options:
+//   - Three per domino: positive at this end, negative at this end, or neutral.
+
+// placement is a single domino state. It records the sign at the domino's
+// top/left end.
+type placement struct {
+	row int
+	col int
+	r   rune
+}
+
+// dlxModel holds the exact cover matrix for a game and the meaning of each
+// option in it.
+type dlxModel struct {
+	matrix     *dlx.Matrix
+	placements []placement
+}
+
+// newDLXModel builds the exact cover matrix for the game.
+func (game *Game) newDLXModel() dlxModel {
+	width := game.frames.Width()
+	height := game.frames.Height()
+	m := dlx.New()
+
+	// Count items. Unknown counts do not constrain anything.
+	countItem := func(count int) int {
+		if count < 0 {
+			return -1
+		}
+		return m.AddPrimary(count)
+	}
+	rowItems := map[rune][]int{}
+	colItems := map[rune][]int{}
+	for _, r := range []rune{common.Positive, common.Negative} {
+		for row := 0; row < height; row++ {
+			rowItems[r] = append(rowItems[r], countItem(game.CountRow(row, r)))
+		}
+		for col := 0; col < width; col++ {
+			colItems[r] = append(colItems[r], countItem(game.CountCol(col, r)))
+		}
+	}
+	// The neutral counts include the walls, which are not part of any option.
+	for row := 0; row < height; row++ {
+		count := game.CountRow(row, common.Neutral)
+		if count >= 0 {
+			count -= game.frames.CountRow(row, common.Wall)
+		}
+		rowItems[common.Neutral] = append(rowItems[common.Neutral], countItem(count))
+	}
+	for col := 0; col < width; col++ {
+		count := game.CountCol(col, common.Neutral)
+		if count >= 0 {
+			count -= game.frames.CountCol(col, common.Wall)
+		}
+		colItems[common.Neutral] = append(colItems[common.Neutral], countItem(count))
+	}
+
+	// Adjacency items. Each cell looks right and down for neighbors.
+	type pair struct {
+		a board.Coord
+		b board.Coord
+	}
+	adjItems := map[rune]map[pair]int{common.Positive: {}, common.Negative: {}}
+	for cell := range game.frames.Cells() {
+		row, col := cell.Unpack()
+		rowEnd, colEnd := game.GetFrameEnd(row, col)
+		for _, adj := range []board.Coord{{Row: 0, Col: 1}, {Row: 1, Col: 0}} {
+			r, c := row+adj.Row, col+adj.Col
+			if r >= height || c >= width || (r == rowEnd && c == colEnd) {
+				continue
+			}
+			p := pair{a: cell, b: board.Coord{Row: r, Col: c}}
+			for _, sign := range []rune{common.Positive, common.Negative} {
+				adjItems[sign][p] = m.AddSecondary()
+			}
+		}
+	}
+
+	// The items touched by placing sign r in a single cell.
+	cellItems := func(row, col int, r rune) []int {
+		var items []int
+		if i := rowItems[r][row]; i != -1 {
+			items = append(items, i)
+		}
+		if i := colItems[r][col]; i != -1 {
+			items = append(items, i)
+		}
+		here := board.Coord{Row: row, Col: col}
+		for _, adj := range board.Adjacents {
+			there := board.Coord{Row: row + adj.Row, Col: col + adj.Col}
+			if i, ok := adjItems[r][pair{a: here, b: there}]; ok {
+				items = append(items, i)
+			}
+			if i, ok := adjItems[r][pair{a: there, b: here}]; ok {
+				items = append(items, i)
+			}
+		}
+		return items
+	}
+
+	var model dlxModel
+	model.matrix = m
+
+	// Domino items must all exist before any options are added.
+	var frames []board.Coord
+	var frameItems []int
+	for frame := range game.Frames() {
+		frames = append(frames, frame)
+		frameItems = append(frameItems, m.AddPrimary(1))
+	}
+
+	for i, frame := range frames {
+		row, col := frame.Unpack()
+		rowEnd, colEnd := game.GetFrameEnd(row, col)
+		for _, r := range []rune{common.Positive, common.Negative, common.Neutral} {
+			items := []int{frameItems[i]}
+			items = append(items, cellItems(row, col, r)...)
+			items = append(items, cellItems(rowEnd, colEnd, common.Negate(r))...)
+			m.AddOption(items...)
+			model.placements = append(model.placements, placement{row: row, col: col, r: r})
+		}
+	}
+
+	return model
+}
+
+// EnumerateSolutions finds every solution to the game using Dancing Links,
+// calling visit with each one. If visit returns false the enumeration stops.
+// It returns the number of solutions visited, and the context's error if it
+// was cancelled.
+func (game *Game) EnumerateSolutions(ctx context.Context, visit func(board.Board) bool) (int, error) {
+	model := game.newDLXModel()
+
+	return model.matrix.Search(ctx, func(options []int) bool {
+		solution := board.New(game.frames.Width(), game.frames.Height())
+		for cell := range game.frames.Cells(common.Wall) {
+			row, col := cell.Unpack()
+			solution.Set(row, col, common.Wall, false)
+		}
+		for _, opt := range options {
+			p := model.placements[opt]
+			game.SetDomino(solution, p.row, p.col, p.r)
+		}
+		return visit(solution)
+	})
+}
+
+// CountSolutionsDLX returns the total number of valid solutions for the game.
+// It gives the same answer as CountSolutions, but uses Dancing Links.
+func (game *Game) CountSolutionsDLX() int {
+	solutions, _ := game.EnumerateSolutions(context.Background(), func(board.Board) bool { return true })
+	return solutions
+}
@@ -0,0 +1,137 @@
+package magnets
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/erikbryant/magnets/board"
+)
+
+// loadGames reads up to limit games from a testcases file.
+func loadGames(tb testing.TB, file string, limit int) []Game {
+	f, err := os.Open(file)
+	if err != nil {
+		tb.Fatalf("Unable to open testcases %s %s", file, err)
+	}
+	defer f.Close()
+
+	var games []Game
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() && len(games) < limit {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "//") {
+			continue
+		}
+		game, ok := Deserialize(line)
+		if !ok {
+			tb.Fatalf("ERROR: Unable to deserialize %s", line)
+		}
+		games = append(games, game)
+	}
+
+	return games
+}
+
+func TestCountSolutionsDLX(t *testing.T) {
+	testCases := []struct {
+		game     string
+		expected int
+	}{
+		{"2x2:11,00,11,00,TTBB", 0},
+		{"1x2:1,10,1,01,TB", 1},
+		{"1x3:1,100,1,010,TB*", 1},
+		{"2x2:11,11,11,11,TTBB", 2},
+		{"3x2:111,21,111,12,TTTBBB", 1},
+		{"5x2:11011,22,11011,22,LRTLRLRBLR", 4},
+		{"5x5:11212,12211,12202,21112,TTTTTBBBBBTLRT*BLRBTLRLRB", 1},
+		{"5x5:.2..1,3..1.,.2..2,2..2.,LRLRTTLRTBBT*BTTBLRBBLRLR", 1},
+		{"8x7:30122222,2232302,30311231,2321321,LRLRLRLRTLRLRLRTBTLRTLRBTBTTBLRTBTBBTLRBTBLRBLRTBLRLRLRB", 1},
+	}
+
+	for _, testCase := range testCases {
+		game, ok := Deserialize(testCase.game)
+		if !ok {
+			t.Errorf("ERROR: failed to deserialize %s", testCase.game)
+		}
+
+		answer := game.CountSolutionsDLX()
+		if answer != testCase.expected {
+			t.Errorf("ERROR: for %s expected %d got %d", testCase.game, testCase.expected, answer)
+		}
+	}
+}
+
+func TestCountSolutionsDLXUnknownCounts(t *testing.T) {
+	// With some counts unknown, and so the neutral counts of those rows and
+	// cols unknown too, DLX must still find every solution, and agree with
+	// the backtracker.
+	testCases := []struct {
+		game     string
+		expected int
+	}{
+		{"5x5:.2..1,3..1.,.2..2,2..2.,LRLRTTLRTBBT*BTTBLRBBLRLR", 1},
+		{"5x5:1.212,12.11,1.202,21.12,TTTTTBBBBBTLRT*BLRBTLRLRB", 1},
+		{"5x5:.....,12211,12202,.....,TTTTTBBBBBTLRT*BLRBTLRLRB", 2},
+		{"5x5:11212,.....,.....,21112,TTTTTBBBBBTLRT*BLRBTLRLRB", 11},
+		{"5x2:1.011,2.,11.11,.2,LRTLRLRBLR", 4},
+		{"3x3:2.1,1.2,.20,11.,LRTT*BBLR", 1},
+	}
+
+	for _, testCase := range testCases {
+		game, ok := Deserialize(testCase.game)
+		if !ok {
+			t.Fatalf("ERROR: failed to deserialize %s", testCase.game)
+		}
+
+		answer := game.CountSolutionsDLX()
+		if answer != testCase.expected {
+			t.Errorf("ERROR: for %s expected %d got %d", testCase.game, testCase.expected, answer)
+		}
+		backtrack := game.CountSolutions(0, 0)
+		if answer != backtrack {
+			t.Errorf("ERROR: for %s DLX found %d and backtracking %d", testCase.game, answer, backtrack)
+		}
+	}
+}
+
+func TestEnumerateSolutions(t *testing.T) {
+	for _, game := range loadGames(t, "../solver/testcases_solve_fail.txt", 50) {
+		expected := game.CountSolutions(0, 0)
+
+		answer, _ := game.EnumerateSolutions(context.Background(), func(solution board.Board) bool {
+			game.Guess = solution
+			if !game.Solved() {
+				t.Errorf("ERROR: %s enumerated a non-solution", game.serial)
+			}
+			return true
+		})
+		if answer != expected {
+			t.Errorf("ERROR: for %s expected %d got %d", game.serial, expected, answer)
+		}
+	}
+}
+
+func BenchmarkCountSolutions(b *testing.B) {
+	games := loadGames(b, "../solver/testcases_solve_fail.txt", 50)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, game := range games {
+			game.CountSolutions(0, 0)
+		}
+	}
+}
+
+func BenchmarkCountSolutionsDLX(b *testing.B) {
+	games := loadGames(b, "../solver/testcases_solve_fail.txt", 50)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, game := range games {
+			game.CountSolutionsDLX()
+		}
+	}
+}
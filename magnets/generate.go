@@ -0,0 +1,155 @@
+package magnets
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sync"
+)
+
+// GenerateOptions controls how Generate creates games.
+type GenerateOptions struct {
+	// Width and Height of each game. If zero, each game gets a random size
+	// from 2 to 16.
+	Width  int
+	Height int
+
+	// Workers is how many games to generate in parallel. If zero, use one
+	// worker per CPU.
+	Workers int
+
+	// Seed determines the games. The same options always produce the same
+	// games in the same order, no matter how many workers there are.
+	Seed int64
+
+	// Count is how many games to generate. If zero, keep generating until
+	// the context is cancelled.
+	Count int
+}
+
+// jobSeed mixes the generator's seed with a game's sequence number, so that
+// each game gets its own, independent, random number stream.
+func jobSeed(seed int64, seq int) int64 {
+	// SplitMix64 finalizer.
+	z := uint64(seed) + uint64(seq+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
+
+// job creates the game with the given sequence number.
+func (opts GenerateOptions) job(ctx context.Context, seq int) (Game, error) {
+	rng := rand.New(rand.NewSource(jobSeed(opts.Seed, seq)))
+
+	width := opts.Width
+	if width == 0 {
+		width = rng.Intn(15) + 2
+	}
+	height := opts.Height
+	if height == 0 {
+		height = rng.Intn(15) + 2
+	}
+
+	return generate(ctx, width, height, rng)
+}
+
+// Generate creates games that each have exactly one solution, spreading the
+// work across several workers. It calls emit with each game, one at a time and
+// in sequence order. Workers stop when they get too far ahead of emit, so a
+// slow emit slows down the generation. If emit returns an error Generate stops
+// and returns it. Generate returns the context's error if the context is
+// cancelled before it finishes, and an error straight away if no game can have
+// the size asked for.
+func Generate(ctx context.Context, opts GenerateOptions, emit func(Game) error) error {
+	// A 1x1 board has no room for a domino, so no game would ever come.
+	if opts.Width < 0 || opts.Height < 0 || opts.Width == 1 && opts.Height == 1 {
+		return fmt.Errorf("cannot generate %dx%d games", opts.Width, opts.Height)
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		seq  int
+		game Game
+		err  error
+	}
+
+	jobs := make(chan int)
+	results := make(chan result, workers)
+
+	// Each game holds a token from the time it is handed to a worker until
+	// it is emitted. This bounds how far the workers can get ahead.
+	tokens := make(chan struct{}, 2*workers)
+
+	go func() {
+		defer close(jobs)
+		for seq := 0; opts.Count == 0 || seq < opts.Count; seq++ {
+			select {
+			case tokens <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- seq:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for seq := range jobs {
+				game, err := opts.job(ctx, seq)
+				select {
+				case results <- result{seq: seq, game: game, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Games can finish out of order. Hold on to them until it is their turn.
+	pending := map[int]Game{}
+	next := 0
+
+	for r := range results {
+		if r.err != nil {
+			return r.err
+		}
+		pending[r.seq] = r.game
+
+		for {
+			game, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			<-tokens
+
+			err := emit(game)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,114 @@
+package magnets
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// generateSerials returns the serial form of each game Generate creates.
+func generateSerials(t *testing.T, opts GenerateOptions) []string {
+	var serials []string
+
+	err := Generate(context.Background(), opts, func(game Game) error {
+		s, ok := game.Serialize()
+		if !ok {
+			t.Errorf("ERROR: Unable to serialize generated game")
+		}
+		serials = append(serials, s)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("ERROR: Unexpected error %v", err)
+	}
+
+	return serials
+}
+
+func TestGenerate(t *testing.T) {
+	opts := GenerateOptions{Width: 5, Height: 4, Workers: 1, Seed: 42, Count: 10}
+	expected := generateSerials(t, opts)
+
+	if len(expected) != opts.Count {
+		t.Fatalf("ERROR: Expected %d games, got %d", opts.Count, len(expected))
+	}
+
+	for _, s := range expected {
+		game, ok := Deserialize(s)
+		if !ok {
+			t.Errorf("ERROR: Unable to deserialize %s", s)
+			continue
+		}
+		if game.CountSolutions(0, 0) != 1 {
+			t.Errorf("ERROR: Expected %s to have exactly one solution", s)
+		}
+	}
+
+	// The same seed produces the same games, no matter how many workers.
+	for _, workers := range []int{2, 7} {
+		opts.Workers = workers
+		answer := generateSerials(t, opts)
+		for i := range expected {
+			if answer[i] != expected[i] {
+				t.Errorf("ERROR: With %d workers game %d expected %s got %s", workers, i, expected[i], answer[i])
+			}
+		}
+	}
+
+	// A different seed produces different games.
+	opts.Seed++
+	answer := generateSerials(t, opts)
+	same := 0
+	for i := range expected {
+		if answer[i] == expected[i] {
+			same++
+		}
+	}
+	if same == len(expected) {
+		t.Errorf("ERROR: Expected a different seed to produce different games")
+	}
+}
+
+func TestGenerateStop(t *testing.T) {
+	stop := errors.New("stop")
+	games := 0
+
+	err := Generate(context.Background(), GenerateOptions{Width: 3, Height: 3, Workers: 4}, func(Game) error {
+		games++
+		if games == 5 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Errorf("ERROR: Expected %v, got %v", stop, err)
+	}
+	if games != 5 {
+		t.Errorf("ERROR: Expected 5 games, got %d", games)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = Generate(ctx, GenerateOptions{Workers: 4}, func(Game) error { return nil })
+	if err != context.Canceled {
+		t.Errorf("ERROR: Expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestGenerateSize(t *testing.T) {
+	// One cell wide or high is fine as long as there is room for a domino.
+	for _, opts := range []GenerateOptions{{Width: 1, Height: 4}, {Width: 3, Height: 1}, {Width: 1}} {
+		opts.Seed, opts.Count = 1, 2
+		if serials := generateSerials(t, opts); len(serials) != 2 {
+			t.Errorf("ERROR: For %dx%d expected 2 games, got %v", opts.Width, opts.Height, serials)
+		}
+	}
+
+	for _, opts := range []GenerateOptions{{Width: 1, Height: 1}, {Width: -1, Height: 3}, {Height: -2}} {
+		err := Generate(context.Background(), opts, func(Game) error { return nil })
+		if err == nil {
+			t.Errorf("ERROR: Expected an error generating %dx%d games", opts.Width, opts.Height)
+		}
+	}
+}
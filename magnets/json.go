@@ -0,0 +1,227 @@
+package magnets
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/erikbryant/magnets/board"
+	"github.com/erikbryant/magnets/common"
+)
+
+// JSON encoding of a game. Boards are written as one string per row, using
+// the same letters as the serial format:
+//
+//	{
+//	  "width": 3,
+//	  "height": 3,
+//	  "colPos": [2, 0, 1],
+//	  "rowPos": [1, 0, 2],
+//	  "colNeg": [1, 2, 0],
+//	  "rowNeg": [1, 1, 1],
+//	  "frames": ["LRT", "T*B", "BLR"],
+//	  "solution": ["+-#", "-*#", "+-+"],
+//	  "guess": ["...", ".*.", "..."]
+//	}
+//
+// Unknown counts are null. Frames use 'L', 'R', 'T', 'B' and '*'. The
+// solution and guess use '+', '-', '#' (neutral), '*' (wall) and '.'
+// (undecided). The solution is left out if the game does not know it.
+
+// gameJSON is the shape of a game in JSON.
+type gameJSON struct {
+	Width    int      `json:"width"`
+	Height   int      `json:"height"`
+	ColPos   []*int   `json:"colPos"`
+	RowPos   []*int   `json:"rowPos"`
+	ColNeg   []*int   `json:"colNeg"`
+	RowNeg   []*int   `json:"rowNeg"`
+	Frames   []string `json:"frames"`
+	Solution []string `json:"solution,omitempty"`
+	Guess    []string `json:"guess"`
+}
+
+var (
+	// jsonRunes maps board runes to the letters used in JSON.
+	jsonRunes = map[rune]rune{
+		common.Left:     'L',
+		common.Right:    'R',
+		common.Up:       'T',
+		common.Down:     'B',
+		common.Wall:     '*',
+		common.Positive: '+',
+		common.Negative: '-',
+		common.Neutral:  '#',
+		common.Empty:    '.',
+	}
+)
+
+// countsToJSON converts counts to JSON, with null for unknown.
+func countsToJSON(counts []int) []*int {
+	j := make([]*int, len(counts))
+	for i, count := range counts {
+		if count >= 0 {
+			c := count
+			j[i] = &c
+		}
+	}
+	return j
+}
+
+// countsFromJSON converts counts from JSON, with -1 for unknown.
+func countsFromJSON(j []*int, want int, name string) ([]int, error) {
+	if len(j) != want {
+		return nil, fmt.Errorf("%s has %d counts, expected %d", name, len(j), want)
+	}
+	counts := make([]int, len(j))
+	for i, count := range j {
+		counts[i] = -1
+		if count != nil {
+			if *count < 0 {
+				return nil, fmt.Errorf("%s has negative count %d", name, *count)
+			}
+			counts[i] = *count
+		}
+	}
+	return counts, nil
+}
+
+// boardToJSON converts a board to one string per row.
+func boardToJSON(l board.Board) []string {
+	rows := make([]string, l.Height())
+	for row := 0; row < l.Height(); row++ {
+		var s []rune
+		for col := 0; col < l.Width(); col++ {
+			r, ok := jsonRunes[l.Get(row, col, false)]
+			if !ok {
+				r = '!'
+			}
+			s = append(s, r)
+		}
+		rows[row] = string(s)
+	}
+	return rows
+}
+
+// boardFromJSON fills a board from one string per row, accepting only the
+// given board runes.
+func boardFromJSON(l board.Board, rows []string, name string, allowed ...rune) error {
+	letters := map[rune]rune{}
+	for _, r := range allowed {
+		letters[jsonRunes[r]] = r
+	}
+
+	if len(rows) != l.Height() {
+		return fmt.Errorf("%s has %d rows, expected %d", name, len(rows), l.Height())
+	}
+	for row, s := range rows {
+		cells := []rune(s)
+		if len(cells) != l.Width() {
+			return fmt.Errorf("%s row %d has %d cells, expected %d", name, row, len(cells), l.Width())
+		}
+		for col, letter := range cells {
+			r, ok := letters[letter]
+			if !ok {
+				return fmt.Errorf("%s has unexpected '%c' at %d, %d", name, letter, row, col)
+			}
+			l.Set(row, col, r, false)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON returns the JSON encoding of the game.
+func (game Game) MarshalJSON() ([]byte, error) {
+	j := gameJSON{
+		Width:  game.grid.Width(),
+		Height: game.grid.Height(),
+		ColPos: countsToJSON(game.colPos),
+		RowPos: countsToJSON(game.rowPos),
+		ColNeg: countsToJSON(game.colNeg),
+		RowNeg: countsToJSON(game.rowNeg),
+		Frames: boardToJSON(game.frames),
+		Guess:  boardToJSON(game.Guess),
+	}
+
+	if solution, ok := game.Solution(); ok {
+		j.Solution = boardToJSON(solution)
+	}
+
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON sets the game from its JSON encoding. It returns an error if
+// the game is not valid, or if the solution does not solve it.
+func (game *Game) UnmarshalJSON(data []byte) error {
+	var j gameJSON
+	err := json.Unmarshal(data, &j)
+	if err != nil {
+		return err
+	}
+
+	if j.Width <= 0 || j.Height <= 0 || j.Width*j.Height <= 1 {
+		return fmt.Errorf("invalid game dimensions %dx%d", j.Width, j.Height)
+	}
+
+	g := makeGame(j.Width, j.Height)
+
+	g.colPos, err = countsFromJSON(j.ColPos, j.Width, "colPos")
+	if err != nil {
+		return err
+	}
+	g.rowPos, err = countsFromJSON(j.RowPos, j.Height, "rowPos")
+	if err != nil {
+		return err
+	}
+	g.colNeg, err = countsFromJSON(j.ColNeg, j.Width, "colNeg")
+	if err != nil {
+		return err
+	}
+	g.rowNeg, err = countsFromJSON(j.RowNeg, j.Height, "rowNeg")
+	if err != nil {
+		return err
+	}
+
+	err = boardFromJSON(g.frames, j.Frames, "frames", common.Left, common.Right, common.Up, common.Down, common.Wall)
+	if err != nil {
+		return err
+	}
+	for cell := range g.frames.Cells(common.Wall) {
+		row, col := cell.Unpack()
+		g.grid.Set(row, col, common.Wall, false)
+	}
+	if !g.Valid() {
+		return fmt.Errorf("invalid domino layout")
+	}
+
+	if j.Solution != nil {
+		err = boardFromJSON(g.grid, j.Solution, "solution", common.Positive, common.Negative, common.Neutral, common.Wall)
+		if err != nil {
+			return err
+		}
+		if !g.Valid() || !g.solves(g.grid) {
+			return fmt.Errorf("solution does not solve the game")
+		}
+	}
+
+	if j.Guess != nil {
+		err = boardFromJSON(g.Guess, j.Guess, "guess", common.Positive, common.Negative, common.Neutral, common.Wall, common.Empty)
+		if err != nil {
+			return err
+		}
+		for cell := range g.Guess.Cells() {
+			row, col := cell.Unpack()
+			if (g.Guess.Get(row, col, false) == common.Wall) != (g.frames.Get(row, col, false) == common.Wall) {
+				return fmt.Errorf("guess and frames disagree about the wall at %d, %d", row, col)
+			}
+		}
+	} else {
+		for cell := range g.frames.Cells(common.Wall) {
+			row, col := cell.Unpack()
+			g.Guess.Set(row, col, common.Wall, false)
+		}
+	}
+
+	*game = g
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package magnets
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/erikbryant/magnets/common"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	game, ok := Deserialize("3x3:2.1,102,120,111,LRTT*BBLR,+#--")
+	if !ok {
+		t.Fatalf("ERROR: Unable to deserialize game")
+	}
+	game.Guess.Set(0, 0, common.Positive, false)
+
+	expected := `{"width":3,"height":3,"colPos":[2,null,1],"rowPos":[1,0,2],"colNeg":[1,2,0],"rowNeg":[1,1,1],"frames":["LRT","T*B","BLR"],"solution":["+-#","-*#","+-+"],"guess":["+..",".*.","..."]}`
+
+	answer, err := json.Marshal(game)
+	if err != nil {
+		t.Errorf("ERROR: Unable to marshal game: %s", err)
+	}
+	if string(answer) != expected {
+		t.Errorf("ERROR: Expected %s got %s", expected, answer)
+	}
+
+	// Without a solution, there is no solution field.
+	game, _ = Deserialize("3x3:201,102,120,111,LRTT*BBLR")
+	expected = `{"width":3,"height":3,"colPos":[2,0,1],"rowPos":[1,0,2],"colNeg":[1,2,0],"rowNeg":[1,1,1],"frames":["LRT","T*B","BLR"],"guess":["...",".*.","..."]}`
+
+	answer, err = json.Marshal(&game)
+	if err != nil {
+		t.Errorf("ERROR: Unable to marshal game: %s", err)
+	}
+	if string(answer) != expected {
+		t.Errorf("ERROR: Expected %s got %s", expected, answer)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		j      string
+		serial string
+	}{
+		{`{"width":3,"height":3,"colPos":[2,null,1],"rowPos":[1,0,2],"colNeg":[1,2,0],"rowNeg":[1,1,1],"frames":["LRT","T*B","BLR"],"solution":["+-#","-*#","+-+"],"guess":["+..",".*.","..."]}`, "3x3:2.1,102,120,111,LRTT*BBLR"},
+		{`{"width":3,"height":3,"colPos":[2,0,1],"rowPos":[1,0,2],"colNeg":[1,2,0],"rowNeg":[1,1,1],"frames":["LRT","T*B","BLR"]}`, "3x3:201,102,120,111,LRTT*BBLR"},
+
+		// Invalid
+		{`{"width":0,"height":3}`, ""},
+		{`{"width":3,"height":3,"colPos":[2,0],"rowPos":[1,0,2],"colNeg":[1,2,0],"rowNeg":[1,1,1],"frames":["LRT","T*B","BLR"]}`, ""},
+		{`{"width":3,"height":3,"colPos":[2,0,-1],"rowPos":[1,0,2],"colNeg":[1,2,0],"rowNeg":[1,1,1],"frames":["LRT","T*B","BLR"]}`, ""},
+		{`{"width":3,"height":3,"colPos":[2,0,1],"rowPos":[1,0,2],"colNeg":[1,2,0],"rowNeg":[1,1,1],"frames":["LRT","T*B","BL"]}`, ""},
+		{`{"width":3,"height":3,"colPos":[2,0,1],"rowPos":[1,0,2],"colNeg":[1,2,0],"rowNeg":[1,1,1],"frames":["LRT","TTB","BLR"]}`, ""},
+		{`{"width":3,"height":3,"colPos":[2,0,1],"rowPos":[1,0,2],"colNeg":[1,2,0],"rowNeg":[1,1,1],"frames":["LRT","T*B","BLR"],"solution":["-+#","+*#","+-+"]}`, ""},
+		{`{"width":3,"height":3,"colPos":[2,0,1],"rowPos":[1,0,2],"colNeg":[1,2,0],"rowNeg":[1,1,1],"frames":["LRT","T*B","BLR"],"guess":["...","...","..."]}`, ""},
+		{`not json`, ""},
+	}
+
+	for _, testCase := range testCases {
+		var game Game
+		err := json.Unmarshal([]byte(testCase.j), &game)
+		if testCase.serial == "" {
+			if err == nil {
+				t.Errorf("ERROR: Expected an error for %s", testCase.j)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ERROR: Unable to unmarshal %s: %s", testCase.j, err)
+			continue
+		}
+		serial, _ := game.Serialize()
+		if serial != testCase.serial {
+			t.Errorf("ERROR: For %s expected %s got %s", testCase.j, testCase.serial, serial)
+		}
+
+		// It should round trip.
+		answer, err := json.Marshal(game)
+		if err != nil {
+			t.Errorf("ERROR: Unable to marshal %s: %s", testCase.j, err)
+		}
+		var game2 Game
+		err = json.Unmarshal(answer, &game2)
+		if err != nil {
+			t.Errorf("ERROR: Unable to unmarshal %s: %s", answer, err)
+		}
+		if !game2.grid.Equal(game.grid) || !game2.Guess.Equal(game.Guess) || !game2.frames.Equal(game.frames) {
+			t.Errorf("ERROR: %s did not round trip, got %s", testCase.j, answer)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package magnets
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
+	"slices"
 	"time"
 
 	"github.com/erikbryant/magnets/board"
@@ -10,6 +12,9 @@ import (
 )
 
 // Game contains all of the representations to hold state for a game of magnets.
+// Its boards and counts are shared by copies of the game, so a copy made by
+// assignment sees changes to the original's Guess. Use Clone for a game that
+// can be changed independently.
 type Game struct {
 	frames board.Board
 	grid   board.Board
@@ -32,10 +37,10 @@ func init() {
 
 // setFrameMagnet sets the polarities of a given frame to follow its neighbors.
 // If there are no neighbors, use a random sign.
-func (game *Game) setFrameMagnet(row, col int) {
+func (game *Game) setFrameMagnet(row, col int, rng *rand.Rand) {
 	// Choose a random sign for the new frame.
 	choices := []rune{common.Positive, common.Negative}
-	sign := choices[rand.Intn(len(choices))]
+	sign := choices[rng.Intn(len(choices))]
 
 	// If there is a neighbor that is already set, follow its polarity instead.
 	for _, mod := range board.Adjacents {
@@ -55,11 +60,15 @@ func (game *Game) setFrameMagnet(row, col int) {
 }
 
 // placeFrames attempts to fill a given board with frames. It keeps
-// trying until it gets a valid solution.
-func (game *Game) placeFrames() {
+// trying until it gets a valid layout or the context is cancelled.
+func (game *Game) placeFrames(ctx context.Context, rng *rand.Rand) error {
 	// This algorithm may sometimes generate an invalid
 	// board frame. Loop until it generates a valid one.
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		for cell := range game.frames.Cells() {
 			row, col := cell.Unpack()
 			if game.frames.Get(row, col, false) != common.Empty {
@@ -71,7 +80,7 @@ func (game *Game) placeFrames() {
 			if game.frames.Get(row, col+1, false) == common.Empty {
 				if game.frames.Get(row+1, col, false) == common.Empty {
 					choices := []rune{common.Right, common.Down}
-					orient = choices[rand.Intn(len(choices))]
+					orient = choices[rng.Intn(len(choices))]
 				} else {
 					orient = common.Right
 				}
@@ -107,8 +116,8 @@ func (game *Game) placeFrames() {
 		}
 
 		// Is this board valid? If so, ship it! :-)
-		if game.Valid() && game.singleSolution() {
-			break
+		if game.Valid() {
+			return nil
 		}
 
 		// Reset the layers and try again.
@@ -121,7 +130,7 @@ func (game *Game) placeFrames() {
 }
 
 // placePieces puts the neutrals and magnets randomly on the board.
-func (game *Game) placePieces() {
+func (game *Game) placePieces(rng *rand.Rand) {
 	// Place all of the neutrals before placing any magnets.
 	// The neutrals have a chance to form walls that bound
 	// disconnected areas. Placing the magnets calls flood
@@ -129,7 +138,7 @@ func (game *Game) placePieces() {
 	// board.
 	for frame := range game.Frames() {
 		// Random chance to add a neutral.
-		if rand.Intn(10) != 0 {
+		if rng.Intn(10) != 0 {
 			continue
 		}
 		row, col := frame.Unpack()
@@ -146,7 +155,7 @@ func (game *Game) placePieces() {
 		if game.grid.Get(row, col, false) != common.Empty {
 			continue
 		}
-		game.setFrameMagnet(row, col)
+		game.setFrameMagnet(row, col, rng)
 	}
 
 	// Record how many magnets are in each row/col
@@ -160,6 +169,20 @@ func (game *Game) placePieces() {
 	}
 }
 
+// Clone returns a copy of the game that shares no storage with it.
+func (game *Game) Clone() Game {
+	return Game{
+		frames: game.frames.Clone(),
+		grid:   game.grid.Clone(),
+		Guess:  game.Guess.Clone(),
+		colPos: slices.Clone(game.colPos),
+		rowPos: slices.Clone(game.rowPos),
+		colNeg: slices.Clone(game.colNeg),
+		rowNeg: slices.Clone(game.rowNeg),
+		serial: game.serial,
+	}
+}
+
 // makeGame creates an empty game state.
 func makeGame(width, height int) Game {
 	var game Game
@@ -176,16 +199,43 @@ func makeGame(width, height int) Game {
 	return game
 }
 
-// New creates and populates all of the layers that make up a game.
-func New(width, height int) Game {
-	game := makeGame(width, height)
-	game.placeFrames()
-	game.placePieces()
+// generate creates and populates all of the layers that make up a game. It
+// keeps trying until the game has exactly one solution or the context is
+// cancelled. All of the randomness comes from rng.
+func generate(ctx context.Context, width, height int, rng *rand.Rand) (Game, error) {
+	for {
+		game := makeGame(width, height)
+
+		err := game.placeFrames(ctx, rng)
+		if err != nil {
+			return game, err
+		}
 
-	if !game.Valid() {
-		fmt.Println("ERROR: New() board is not valid.")
-		game.Print()
+		game.placePieces(rng)
+
+		if !game.Valid() {
+			fmt.Println("ERROR: New() board is not valid.")
+			game.Print()
+		}
+
+		single, err := game.singleSolution(ctx)
+		if err != nil {
+			return game, err
+		}
+		if single {
+			return game, nil
+		}
 	}
+}
 
+// New creates and populates all of the layers that make up a game.
+func New(width, height int) Game {
+	game, _ := NewContext(context.Background(), width, height)
 	return game
 }
+
+// NewContext creates and populates all of the layers that make up a game. It
+// returns the context's error if the context is cancelled before it finishes.
+func NewContext(ctx context.Context, width, height int) (Game, error) {
+	return generate(ctx, width, height, rand.New(rand.NewSource(rand.Int63())))
+}
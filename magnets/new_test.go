@@ -1,7 +1,10 @@
 package magnets
 
 import (
+	"context"
 	"testing"
+
+	"github.com/erikbryant/magnets/common"
 )
 
 // TODO: write tests for ...
@@ -29,3 +32,44 @@ func TestNew(t *testing.T) {
 		}
 	}
 }
+
+func TestNewContext(t *testing.T) {
+	game, err := NewContext(context.Background(), 3, 4)
+	if err != nil {
+		t.Errorf("ERROR: Unexpected error %v", err)
+	}
+	if !game.Valid() {
+		t.Errorf("ERROR: Expected a valid game")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = NewContext(ctx, 3, 4)
+	if err != context.Canceled {
+		t.Errorf("ERROR: Expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestClone(t *testing.T) {
+	game, ok := Deserialize("3x3:201,102,120,111,LRTT*BBLR,+#--")
+	if !ok {
+		t.Fatalf("ERROR: Unable to deserialize game")
+	}
+
+	c := game.Clone()
+	expected, _ := game.SerializeWithSolution()
+	answer, _ := c.SerializeWithSolution()
+	if answer != expected {
+		t.Errorf("ERROR: Expected %s got %s", expected, answer)
+	}
+
+	// Changes to the clone do not show in the original.
+	c.Guess.Set(0, 0, common.Positive, false)
+	c.grid.Set(0, 0, common.Negative, false)
+	c.frames.Set(0, 0, common.Up, false)
+	c.colPos[0] = 0
+	if game.Guess.Get(0, 0, false) != common.Empty || game.grid.Get(0, 0, false) != common.Positive || game.frames.Get(0, 0, false) != common.Left || game.colPos[0] != 2 {
+		t.Errorf("ERROR: Changing the clone changed the original")
+	}
+}
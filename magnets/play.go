@@ -11,21 +11,27 @@ package magnets
 
 import (
 	"fmt"
+	"iter"
 
 	"github.com/erikbryant/magnets/board"
 	"github.com/erikbryant/magnets/common"
 )
 
-// Valid returns true if the game state is valid, false otherwise.
+// Valid returns true if the game state is valid, false otherwise. Validate
+// says what is wrong.
 func (game *Game) Valid() bool {
+	return game.Validate() == nil
+}
+
+// Validate returns an error saying what is wrong with the game state, or nil
+// if it is valid.
+func (game *Game) Validate() error {
 	// Validate board size bounds.
 	if game.grid.Width() <= 0 || game.grid.Height() <= 0 {
-		fmt.Println("ERROR: dimensions out of bounds.", game.grid.Width(), "x", game.grid.Height())
-		return false
+		return fmt.Errorf("dimensions %dx%d out of bounds", game.grid.Width(), game.grid.Height())
 	}
 	if game.grid.Width()*game.grid.Height() <= 1 {
-		fmt.Println("ERROR: dimensions are too small.", game.grid.Width(), "x", game.grid.Height())
-		return false
+		return fmt.Errorf("dimensions %dx%d are too small", game.grid.Width(), game.grid.Height())
 	}
 
 	// Validate that in frames, every cell is a frame or a wall.
@@ -38,18 +44,15 @@ func (game *Game) Valid() bool {
 			continue
 		}
 		if cell == common.Empty {
-			// The board was not completely filled.
-			return false
+			return fmt.Errorf("frames %d, %d is not filled in", row, col)
 		}
 		rowEnd, colEnd := game.GetFrameEnd(row, col)
 		if rowEnd == -1 && colEnd == -1 {
-			fmt.Printf("ERROR: At frames %d, %d found unexpected '%c'\n", row, col, cell)
-			return false
+			return fmt.Errorf("at frames %d, %d found unexpected '%c'", row, col, cell)
 		}
 		adjacent := game.frames.Get(rowEnd, colEnd, false)
 		if adjacent != common.Negate(cell) {
-			fmt.Printf("ERROR: At frames %d, %d expected '%c', found '%c'\n", rowEnd, colEnd, common.Negate(cell), adjacent)
-			return false
+			return fmt.Errorf("at frames %d, %d expected '%c', found '%c'", rowEnd, colEnd, common.Negate(cell), adjacent)
 		}
 	}
 
@@ -63,12 +66,11 @@ func (game *Game) Valid() bool {
 		case common.Neutral:
 		case common.Wall:
 		case common.Empty:
-			// Valid() is called before the board is populated,
+			// Validate() is called before the board is populated,
 			// so Empty can also be a valid case. Would be nice
 			// to fix that.
 		default:
-			fmt.Printf("ERROR: unexpected grid cell: '%c' at %d x %d\n", grid, row, col)
-			return false
+			return fmt.Errorf("unexpected grid cell: '%c' at %d x %d", grid, row, col)
 		}
 	}
 
@@ -79,8 +81,7 @@ func (game *Game) Valid() bool {
 		rowEnd, colEnd := game.GetFrameEnd(row, col)
 		found := game.grid.Get(rowEnd, colEnd, false)
 		if common.Negate(grid) != found {
-			fmt.Printf("ERROR: wrong sign at %d, %d! Expected '%c' got '%c'\n", row, col, common.Negate(grid), found)
-			return false
+			return fmt.Errorf("wrong sign at %d, %d, expected '%c' got '%c'", row, col, common.Negate(grid), found)
 		}
 	}
 
@@ -91,13 +92,12 @@ func (game *Game) Valid() bool {
 		for _, adj := range board.Adjacents {
 			r, c := adj.Unpack()
 			if game.grid.Get(row+r, col+c, false) == grid {
-				fmt.Printf("ERROR: '%c' sign at %d, %d is not consistent\n", grid, row, col)
-				return false
+				return fmt.Errorf("'%c' sign at %d, %d is not consistent", grid, row, col)
 			}
 		}
 	}
 
-	return true
+	return nil
 }
 
 // SetDomino sets both ends of a domino, given one end.
@@ -152,55 +152,160 @@ func (game *Game) GetFrameEnd(row, col int) (int, int) {
 	return row + r, col + c
 }
 
-// Frames iterates over every frame in the layer, pushing the row/col to a channel.
-// It uses a filter, so if you change the contents of the frame layer while it is
-// iterating it may return inconsistent results.
-func (game *Game) Frames() <-chan board.Coord {
+// Frames iterates over every frame in the layer, yielding the row/col of its
+// top/left end. It uses a filter, so if you change the contents of the frame
+// layer while it is iterating it may return inconsistent results.
+func (game *Game) Frames() iter.Seq[board.Coord] {
 	return game.frames.Cells(common.Up, common.Left)
 }
 
-// Solved checks to see if the guess board has a valid solution.
+// Solved checks to see if the guess board has a valid solution. Counts
+// that are unknown (-1) are not checked.
 func (game *Game) Solved() bool {
-	for row := 0; row < game.Guess.Height(); row++ {
-		if game.Guess.CountRow(row, common.Positive) != game.rowPos[row] {
+	return game.solves(game.Guess)
+}
+
+// Solution returns a copy of the game's solution, and false if the game
+// does not know its solution (e.g., it was deserialized without one).
+func (game *Game) Solution() (board.Board, bool) {
+	solution := game.grid.Clone()
+	for range solution.Cells(common.Empty) {
+		return solution, false
+	}
+	return solution, true
+}
+
+// Regions returns the connected regions of magnets in the solution. Neutral
+// dominoes and walls separate them. It returns nil if the game does not know
+// its solution.
+func (game *Game) Regions() []board.Region {
+	if _, ok := game.Solution(); !ok {
+		return nil
+	}
+	return game.grid.Components(common.Positive, common.Negative)
+}
+
+// solves checks to see if the given board is a valid solution.
+func (game *Game) solves(l board.Board) bool {
+	for row := 0; row < l.Height(); row++ {
+		if l.CountRow(row, common.Empty) != 0 {
+			return false
+		}
+		if count := game.CountRow(row, common.Positive); count >= 0 && l.CountRow(row, common.Positive) != count {
 			return false
 		}
-		if game.Guess.CountRow(row, common.Negative) != game.rowNeg[row] {
+		if count := game.CountRow(row, common.Negative); count >= 0 && l.CountRow(row, common.Negative) != count {
 			return false
 		}
-		if game.Guess.CountRow(row, common.Neutral)+game.Guess.CountRow(row, common.Wall) != game.Guess.Width()-(game.rowPos[row]+game.rowNeg[row]) {
+		if count := game.CountRow(row, common.Neutral); count >= 0 && l.CountRow(row, common.Neutral)+l.CountRow(row, common.Wall) != count {
 			return false
 		}
 	}
 
-	for col := 0; col < game.Guess.Width(); col++ {
-		if game.Guess.CountCol(col, common.Positive) != game.colPos[col] {
+	for col := 0; col < l.Width(); col++ {
+		if count := game.CountCol(col, common.Positive); count >= 0 && l.CountCol(col, common.Positive) != count {
 			return false
 		}
-		if game.Guess.CountCol(col, common.Negative) != game.colNeg[col] {
+		if count := game.CountCol(col, common.Negative); count >= 0 && l.CountCol(col, common.Negative) != count {
 			return false
 		}
-		if game.Guess.CountCol(col, common.Neutral)+game.Guess.CountCol(col, common.Wall) != game.Guess.Height()-(game.colPos[col]+game.colNeg[col]) {
+		if count := game.CountCol(col, common.Neutral); count >= 0 && l.CountCol(col, common.Neutral)+l.CountCol(col, common.Wall) != count {
 			return false
 		}
 	}
 
 	// Validate that there are no two identical signs next to each other.
+	for cell := range l.Cells(common.Positive, common.Negative) {
+		row, col := cell.Unpack()
+		grid := l.Get(row, col, false)
+		for _, adj := range board.Adjacents {
+			r, c := adj.Unpack()
+			if l.Get(row+r, col+c, false) == grid {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// Conflicts returns the cells of the guess board that break a rule: like
+// signs next to each other, a domino whose ends do not go together, or a
+// row/col that has too many of a sign (or, once it is full, the wrong number).
+// Each cell is listed once, in row-major order.
+func (game *Game) Conflicts() []board.Coord {
+	conflict := board.New(game.Guess.Width(), game.Guess.Height())
+	mark := func(row, col int) {
+		if game.Guess.Get(row, col, false) != common.Wall {
+			conflict.Set(row, col, common.Marker, false)
+		}
+	}
+
+	// Like signs next to each other.
 	for cell := range game.Guess.Cells(common.Positive, common.Negative) {
 		row, col := cell.Unpack()
 		grid := game.Guess.Get(row, col, false)
 		for _, adj := range board.Adjacents {
 			r, c := adj.Unpack()
 			if game.Guess.Get(row+r, col+c, false) == grid {
-				return false
+				mark(row, col)
 			}
 		}
 	}
 
-	return true
+	// Domino ends that do not go together.
+	for frame := range game.Frames() {
+		row, col := frame.Unpack()
+		rowEnd, colEnd := game.GetFrameEnd(row, col)
+		end1 := game.Guess.Get(row, col, false)
+		end2 := game.Guess.Get(rowEnd, colEnd, false)
+		if end1 == common.Empty || end2 == common.Empty {
+			continue
+		}
+		if end2 != common.Negate(end1) {
+			mark(row, col)
+			mark(rowEnd, colEnd)
+		}
+	}
+
+	// Rows and cols with the wrong counts.
+	wrong := func(found, empty, want int) bool {
+		if want < 0 {
+			return false
+		}
+		return found > want || (empty == 0 && found != want)
+	}
+	for row := 0; row < game.Guess.Height(); row++ {
+		empty := game.Guess.CountRow(row, common.Empty)
+		for _, r := range []rune{common.Positive, common.Negative} {
+			if wrong(game.Guess.CountRow(row, r), empty, game.CountRow(row, r)) {
+				for col := 0; col < game.Guess.Width(); col++ {
+					mark(row, col)
+				}
+			}
+		}
+	}
+	for col := 0; col < game.Guess.Width(); col++ {
+		empty := game.Guess.CountCol(col, common.Empty)
+		for _, r := range []rune{common.Positive, common.Negative} {
+			if wrong(game.Guess.CountCol(col, r), empty, game.CountCol(col, r)) {
+				for row := 0; row < game.Guess.Height(); row++ {
+					mark(row, col)
+				}
+			}
+		}
+	}
+
+	var conflicts []board.Coord
+	for cell := range conflict.Cells(common.Marker) {
+		conflicts = append(conflicts, cell)
+	}
+
+	return conflicts
 }
 
 // CountRow counts the number of occurrences of the given rune in a row.
+// For positive, negative, and neutral it returns -1 if the count is unknown.
 func (game *Game) CountRow(row int, r rune) int {
 	if r == common.Positive {
 		return game.rowPos[row]
@@ -209,12 +314,16 @@ func (game *Game) CountRow(row int, r rune) int {
 		return game.rowNeg[row]
 	}
 	if r == common.Neutral {
+		if game.rowPos[row] < 0 || game.rowNeg[row] < 0 {
+			return -1
+		}
 		return game.grid.Width() - (game.rowPos[row] + game.rowNeg[row])
 	}
 	return game.grid.CountRow(row, r)
 }
 
 // CountCol counts the number of occurrences of the given rune in a column.
+// For positive, negative, and neutral it returns -1 if the count is unknown.
 func (game *Game) CountCol(col int, r rune) int {
 	if r == common.Positive {
 		return game.colPos[col]
@@ -223,7 +332,31 @@ func (game *Game) CountCol(col int, r rune) int {
 		return game.colNeg[col]
 	}
 	if r == common.Neutral {
+		if game.colPos[col] < 0 || game.colNeg[col] < 0 {
+			return -1
+		}
 		return game.grid.Height() - (game.colPos[col] + game.colNeg[col])
 	}
 	return game.grid.CountCol(col, r)
 }
+
+// Clues returns the row and column counts in serial order: col positive, row
+// positive, col negative, row negative. Unknown counts are -1.
+func (game *Game) Clues() []int {
+	var clues []int
+	for _, counts := range game.counts() {
+		clues = append(clues, *counts...)
+	}
+	return clues
+}
+
+// HideClue makes the count at index i of Clues unknown.
+func (game *Game) HideClue(i int) {
+	for _, counts := range game.counts() {
+		if i < len(*counts) {
+			(*counts)[i] = -1
+			return
+		}
+		i -= len(*counts)
+	}
+}
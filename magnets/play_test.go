@@ -1,5 +1,14 @@
 package magnets
 
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/erikbryant/magnets/board"
+	"github.com/erikbryant/magnets/common"
+)
+
 // Print() is trivial and does not need a test.
 // Solved() is trivial and does not need a test.
 // CountRow() is trivial and does not need a test.
@@ -14,3 +23,92 @@ package magnets
 // Solved()
 // CountRow()
 // CountCol()
+
+func TestConflicts(t *testing.T) {
+	testCases := []struct {
+		guess    []string
+		expected []board.Coord
+	}{
+		// Nothing placed yet.
+		{[]string{"...", ".*.", "..."}, nil},
+		// The solution.
+		{[]string{"+-#", "-*#", "+-+"}, nil},
+		// Like signs next to each other, which also puts too many
+		// positives in row 0 and col 1.
+		{[]string{"++.", ".*.", "..."}, []board.Coord{{Row: 0, Col: 0}, {Row: 0, Col: 1}, {Row: 0, Col: 2}, {Row: 2, Col: 1}}},
+		// A domino with one neutral end, which also puts a positive in
+		// row 1.
+		{[]string{"+-#", ".*+", "..."}, []board.Coord{{Row: 0, Col: 2}, {Row: 1, Col: 0}, {Row: 1, Col: 2}}},
+		// Too many negatives in col 2.
+		{[]string{"...", ".*-", "+-+"}, []board.Coord{{Row: 0, Col: 2}, {Row: 1, Col: 2}, {Row: 2, Col: 2}}},
+		// Row 0 is full, but has too few signs.
+		{[]string{"###", ".*.", "..."}, []board.Coord{{Row: 0, Col: 0}, {Row: 0, Col: 1}, {Row: 0, Col: 2}}},
+	}
+
+	for _, testCase := range testCases {
+		game, ok := Deserialize("3x3:201,102,120,111,LRTT*BBLR")
+		if !ok {
+			t.Fatalf("ERROR: Unable to deserialize game")
+		}
+		err := boardFromJSON(game.Guess, testCase.guess, "guess", common.Positive, common.Negative, common.Neutral, common.Wall, common.Empty)
+		if err != nil {
+			t.Fatalf("ERROR: Bad guess %v: %s", testCase.guess, err)
+		}
+
+		answer := game.Conflicts()
+		if !slices.Equal(answer, testCase.expected) {
+			t.Errorf("ERROR: For %v expected %v got %v", testCase.guess, testCase.expected, answer)
+		}
+	}
+}
+
+func TestRegions(t *testing.T) {
+	// +-#
+	// -*#
+	// +-+
+	game, _ := Deserialize("3x3:201,102,120,111,LRTT*BBLR,+#--")
+	answer := game.Regions()
+	if len(answer) != 1 || len(answer[0]) != 6 {
+		t.Errorf("ERROR: Expected one region of 6 cells got %v", answer)
+	}
+
+	// Without a solution there are no regions.
+	game, _ = Deserialize("3x3:201,102,120,111,LRTT*BBLR")
+	answer = game.Regions()
+	if answer != nil {
+		t.Errorf("ERROR: Expected no regions got %v", answer)
+	}
+}
+
+func TestHideClue(t *testing.T) {
+	long := "1x124:[62]," + strings.Repeat("10", 62) + ",[62]," + strings.Repeat("01", 62) + "," + strings.Repeat("TB", 62)
+
+	testCases := []struct {
+		serial   string
+		i        int
+		expected string
+	}{
+		{"3x3:201,102,120,111,LRTT*BBLR", 0, "3x3:.01,102,120,111,LRTT*BBLR"},
+		{"3x3:201,102,120,111,LRTT*BBLR", 5, "3x3:201,10.,120,111,LRTT*BBLR"},
+		{"3x3:201,102,120,111,LRTT*BBLR", 11, "3x3:201,102,120,11.,LRTT*BBLR"},
+		// Counts over 61 are one clue each.
+		{long, 125, strings.Replace(long, ",[62],", ",.,", 1)},
+	}
+
+	for _, testCase := range testCases {
+		game, ok := Deserialize(testCase.serial)
+		if !ok {
+			t.Fatalf("ERROR: Unable to deserialize %s", testCase.serial)
+		}
+		clues := game.Clues()
+		game.HideClue(testCase.i)
+		answer, _ := game.Serialize()
+		if answer != testCase.expected {
+			t.Errorf("ERROR: For %s %d expected %s got %s", testCase.serial, testCase.i, testCase.expected, answer)
+		}
+		clues[testCase.i] = -1
+		if !slices.Equal(game.Clues(), clues) {
+			t.Errorf("ERROR: For %s %d expected clues %v got %v", testCase.serial, testCase.i, clues, game.Clues())
+		}
+	}
+}
@@ -54,14 +54,29 @@ import (
 // w*h-sized string of 'L', 'R', 'T', 'B' for domino associations,
 //   or '*' for a black singleton square.
 //
+// Optionally, the solution:
+//
+// comma
+// one of '+', '-', '#' per domino, giving the sign of its top/left end.
+//   The dominoes are in the order their top/left ends appear, reading
+//   across then down. '#' is a neutral domino.
+//
+// The 3x3 example above, with its solution:
+//
+// 3x3:201,102,120,111,LRTT*BBLR,+#--
+//
 // There is only one character position allocated for the count. So, if a
 // count is greater than 9 it rolls to alpha characters. First lowercase,
-// then uppercase.
+// then uppercase. A count greater than 61 is written in decimal between
+// brackets, which Simon's puzzle does not read:
+//
+// 1x124:[62],1010...10,[62],0101...01,TBTB...TB
 
-// countToRune returns the base-62 form of an int. Valid input is 0-61.
+// countToRune returns the base-62 form of an int. Valid input is 0-61. An
+// unknown count (-1) is '.', as in Simon Tatham's puzzle.
 func countToRune(count int) rune {
 	if count < 0 {
-		return '-'
+		return '.'
 	}
 
 	// 0-9
@@ -109,6 +124,42 @@ func runeToCount(r rune) int {
 	return -1
 }
 
+// countToString returns the serial form of a count: a single base-62
+// character if it fits, otherwise the count in brackets.
+func countToString(count int) string {
+	if count > 9+26+26 {
+		return "[" + strconv.Itoa(count) + "]"
+	}
+	return string(countToRune(count))
+}
+
+// parseCounts reads n counts from the start of s, returning them and the rest
+// of s. It returns false if s is too short or a bracketed count is malformed.
+func parseCounts(s string, n int) ([]int, string, bool) {
+	counts := make([]int, n)
+	for i := range counts {
+		if s == "" {
+			return counts, s, false
+		}
+		if s[0] != '[' {
+			counts[i] = runeToCount(rune(s[0]))
+			s = s[1:]
+			continue
+		}
+		end := strings.IndexRune(s, ']')
+		if end == -1 {
+			return counts, s, false
+		}
+		count, err := strconv.Atoi(s[1:end])
+		if err != nil || count < 0 {
+			return counts, s, false
+		}
+		counts[i] = count
+		s = s[end+1:]
+	}
+	return counts, s, true
+}
+
 // Serialize returns a representation of the game in string form.
 func (game *Game) Serialize() (string, bool) {
 	if !game.Valid() {
@@ -124,28 +175,28 @@ func (game *Game) Serialize() (string, bool) {
 	// Col positive count
 	for col := 0; col < game.grid.Width(); col++ {
 		count := game.CountCol(col, common.Positive)
-		serial += string(countToRune(count))
+		serial += countToString(count)
 	}
 	serial += ","
 
 	// Row positive count
 	for row := 0; row < game.grid.Height(); row++ {
 		count := game.CountRow(row, common.Positive)
-		serial += string(countToRune(count))
+		serial += countToString(count)
 	}
 	serial += ","
 
 	// Col negative count
 	for col := 0; col < game.grid.Width(); col++ {
 		count := game.CountCol(col, common.Negative)
-		serial += string(countToRune(count))
+		serial += countToString(count)
 	}
 	serial += ","
 
 	// Row negative count
 	for row := 0; row < game.grid.Height(); row++ {
 		count := game.CountRow(row, common.Negative)
-		serial += string(countToRune(count))
+		serial += countToString(count)
 	}
 	serial += ","
 
@@ -172,66 +223,83 @@ func (game *Game) Serialize() (string, bool) {
 	return serial, valid
 }
 
-// Deserialize takes a serial representation of a game and unpacks it,
-// returning a game and whether or not the unpacking was successful.
-func Deserialize(s string) (Game, bool) {
-	xPos := strings.IndexRune(s, 'x')
-	if xPos == -1 {
-		return makeGame(0, 0), false
+// SerializeWithSolution returns a representation of the game in string form,
+// including the solution. It fails if the game does not have a solution.
+func (game *Game) SerializeWithSolution() (string, bool) {
+	serial, ok := game.Serialize()
+	if !ok {
+		return serial, false
 	}
 
+	serial += ","
+	for frame := range game.Frames() {
+		row, col := frame.Unpack()
+		switch game.grid.Get(row, col, false) {
+		case common.Positive:
+			serial += "+"
+		case common.Negative:
+			serial += "-"
+		case common.Neutral:
+			serial += "#"
+		default:
+			return serial, false
+		}
+	}
+
+	return serial, true
+}
+
+// Deserialize takes a serial representation of a game, or its code, and
+// unpacks it, returning a game and whether or not the unpacking was
+// successful.
+func Deserialize(s string) (Game, bool) {
 	colonPos := strings.IndexRune(s, ':')
 	if colonPos == -1 {
+		// It is a code (see binary.go).
+		return decodeCode(s)
+	}
+
+	xPos := strings.IndexRune(s[:colonPos], 'x')
+	if xPos == -1 {
 		return makeGame(0, 0), false
 	}
 
-	width, _ := strconv.Atoi(s[0:xPos])
-	height, _ := strconv.Atoi(s[xPos+1 : colonPos])
+	width, err := strconv.Atoi(s[0:xPos])
+	if err != nil || width < 1 {
+		return makeGame(0, 0), false
+	}
+	height, err := strconv.Atoi(s[xPos+1 : colonPos])
+	if err != nil || height < 1 {
+		return makeGame(0, 0), false
+	}
 	game := makeGame(width, height)
 	game.serial = s
 	s = s[colonPos+1:]
 
-	// Col positive count
-	for i, r := range s[:width] {
-		game.colPos[i] = runeToCount(r)
-	}
-	s = s[width:]
-	if s[0] != ',' {
-		return game, false
-	}
-	s = s[1:]
-
-	// Row positive count
-	for i, r := range s[:height] {
-		game.rowPos[i] = runeToCount(r)
-	}
-	s = s[height:]
-	if s[0] != ',' {
-		return game, false
+	// Col positive, row positive, col negative and row negative counts,
+	// each followed by a comma.
+	var ok bool
+	for _, counts := range []*[]int{&game.colPos, &game.rowPos, &game.colNeg, &game.rowNeg} {
+		*counts, s, ok = parseCounts(s, len(*counts))
+		if !ok || s == "" || s[0] != ',' {
+			return game, false
+		}
+		s = s[1:]
 	}
-	s = s[1:]
 
-	// Col negative count
-	for i, r := range s[:width] {
-		game.colNeg[i] = runeToCount(r)
-	}
-	s = s[width:]
-	if s[0] != ',' {
-		return game, false
+	// The solution, if there is one, follows the frames.
+	solution := ""
+	hasSolution := false
+	if comma := strings.IndexRune(s, ','); comma != -1 {
+		solution = s[comma+1:]
+		hasSolution = true
+		s = s[:comma]
 	}
-	s = s[1:]
 
-	// Row negative count
-	for i, r := range s[:height] {
-		game.rowNeg[i] = runeToCount(r)
-	}
-	s = s[height:]
-	if s[0] != ',' {
+	// Place frames
+	if len(s) != width*height {
 		return game, false
 	}
-	s = s[1:]
-
-	// Place frames
 	row := 0
 	col := 0
 	for _, cell := range s {
@@ -262,9 +330,49 @@ func Deserialize(s string) (Game, bool) {
 		}
 	}
 
+	if hasSolution && !game.loadSolution(solution) {
+		return game, false
+	}
+
 	return game, true
 }
 
+// loadSolution places the signs of the dominoes, as written by
+// SerializeWithSolution, into the grid. It returns false if they are
+// malformed or do not solve the game.
+func (game *Game) loadSolution(solution string) bool {
+	if !game.Valid() {
+		return false
+	}
+
+	signs := []rune(solution)
+	i := 0
+	for frame := range game.Frames() {
+		if i >= len(signs) {
+			return false
+		}
+		row, col := frame.Unpack()
+		switch signs[i] {
+		case '+':
+			game.SetDomino(game.grid, row, col, common.Positive)
+		case '-':
+			game.SetDomino(game.grid, row, col, common.Negative)
+		case '#':
+			rowEnd, colEnd := game.GetFrameEnd(row, col)
+			game.grid.Set(row, col, common.Neutral, false)
+			game.grid.Set(rowEnd, colEnd, common.Neutral, false)
+		default:
+			return false
+		}
+		i++
+	}
+	if i != len(signs) {
+		return false
+	}
+
+	return game.solves(game.grid)
+}
+
 // Print prints an ASCII representation of the board.
 func (game *Game) Print() {
 	fmt.Printf("\n")
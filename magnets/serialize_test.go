@@ -1,6 +1,8 @@
 package magnets
 
 import (
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/erikbryant/magnets/board"
@@ -12,7 +14,7 @@ func TestCountToRune(t *testing.T) {
 		n        int
 		expected rune
 	}{
-		{-1, '-'},
+		{-1, '.'},
 		{0, '0'},
 		{1, '1'},
 		{9, '9'},
@@ -40,6 +42,7 @@ func TestRuneToCount(t *testing.T) {
 		n        rune
 	}{
 		{-1, '-'},
+		{-1, '.'},
 		{0, '0'},
 		{1, '1'},
 		{9, '9'},
@@ -132,9 +135,33 @@ func TestDeserialize(t *testing.T) {
 		{"1x2:110101TB", false},
 		// List of negatives is short
 		{"5x2:11011,22,1101,22,LRTLRLRBLR", false},
+		// Frames are short
+		{"3x3:201,102,120,111,LRTT*BBL", false},
+		// Frames are long
+		{"3x3:201,102,120,111,LRTT*BBLRLR", false},
+
+		// Bad sizes
+		{"0x3:,102,,111,", false},
+		{"ax3:201,102,120,111,LRTT*BBLR", false},
+		// Bracketed counts are malformed
+		{"3x3:[2]01,102,120,111,LRTT*BBLR", true},
+		{"3x3:[201,102,120,111,LRTT*BBLR", false},
+		{"3x3:[x]01,102,120,111,LRTT*BBLR", false},
+		{"3x3:[-2]01,102,120,111,LRTT*BBLR", false},
+
+		// Solution has the wrong sign
+		{"3x3:201,102,120,111,LRTT*BBLR,+#-+", false},
+		// Solution is short
+		{"3x3:201,102,120,111,LRTT*BBLR,+#-", false},
+		// Solution is long
+		{"3x3:201,102,120,111,LRTT*BBLR,+#--+", false},
+		// Solution has an unknown sign
+		{"3x3:201,102,120,111,LRTT*BBLR,+#-?", false},
 
 		// Valid
 		{"4x5:2022,12021,2013,21201,TTTTBBBBTLRTBLRBLRLR", true},
+		{"3x3:201,102,120,111,LRTT*BBLR,+#--", true},
+		{"3x3:2..,102,...,111,LRTT*BBLR,+#--", true},
 	}
 
 	for _, testCase := range testCases {
@@ -144,3 +171,117 @@ func TestDeserialize(t *testing.T) {
 		}
 	}
 }
+
+func TestCountToString(t *testing.T) {
+	testCases := []struct {
+		n        int
+		expected string
+	}{
+		{-1, "."},
+		{0, "0"},
+		{61, "Z"},
+		{62, "[62]"},
+		{1234, "[1234]"},
+	}
+
+	for _, testCase := range testCases {
+		answer := countToString(testCase.n)
+		if answer != testCase.expected {
+			t.Errorf("ERROR: For %d expected %s got %s", testCase.n, testCase.expected, answer)
+		}
+	}
+}
+
+func TestParseCounts(t *testing.T) {
+	testCases := []struct {
+		s        string
+		n        int
+		expected []int
+		rest     string
+		ok       bool
+	}{
+		{"201,", 3, []int{2, 0, 1}, ",", true},
+		{"2.Z", 3, []int{2, -1, 61}, "", true},
+		{"[62]0[100],", 3, []int{62, 0, 100}, ",", true},
+		{"[7]", 1, []int{7}, "", true},
+		{"20", 3, nil, "", false},
+		{"[62", 1, nil, "", false},
+		{"[]", 1, nil, "", false},
+	}
+
+	for _, testCase := range testCases {
+		counts, rest, ok := parseCounts(testCase.s, testCase.n)
+		if ok != testCase.ok {
+			t.Errorf("ERROR: For %s expected %t got %t", testCase.s, testCase.ok, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if !slices.Equal(counts, testCase.expected) || rest != testCase.rest {
+			t.Errorf("ERROR: For %s expected %v %q got %v %q", testCase.s, testCase.expected, testCase.rest, counts, rest)
+		}
+	}
+}
+
+func TestSerializeLargeCounts(t *testing.T) {
+	// A single column of 62 dominoes, all with + at the top, has 62 of
+	// each sign in the column.
+	serial := "1x124:[62]," + strings.Repeat("10", 62) + ",[62]," + strings.Repeat("01", 62) + "," + strings.Repeat("TB", 62)
+	solution := strings.Repeat("+", 62)
+
+	game, ok := Deserialize(serial + "," + solution)
+	if !ok {
+		t.Fatalf("ERROR: Unable to deserialize %s", serial)
+	}
+	if game.CountCol(0, common.Positive) != 62 || game.CountCol(0, common.Negative) != 62 {
+		t.Errorf("ERROR: Expected 62 and 62 got %d and %d", game.CountCol(0, common.Positive), game.CountCol(0, common.Negative))
+	}
+	answer, ok := game.SerializeWithSolution()
+	if !ok || answer != serial+","+solution {
+		t.Errorf("ERROR: Expected %s got %s", serial+","+solution, answer)
+	}
+}
+
+func TestSerializeWithSolution(t *testing.T) {
+	testCases := []string{
+		"3x3:201,102,120,111,LRTT*BBLR,+#--",
+		"5x4:12222,2322,12222,3222,TLRTTBTTBBTBBLRBLRLR,-++--+#+--",
+	}
+
+	for _, testCase := range testCases {
+		game, ok := Deserialize(testCase)
+		if !ok {
+			t.Errorf("ERROR: Unable to deserialize %s", testCase)
+			continue
+		}
+		answer, ok := game.SerializeWithSolution()
+		if !ok {
+			t.Errorf("ERROR: Unable to serialize %s", testCase)
+		}
+		if answer != testCase {
+			t.Errorf("ERROR: Expected %s got %s", testCase, answer)
+		}
+	}
+
+	// A game without a solution cannot be serialized with one.
+	game, _ := Deserialize("3x3:201,102,120,111,LRTT*BBLR")
+	_, ok := game.SerializeWithSolution()
+	if ok {
+		t.Errorf("ERROR: Expected failure serializing a game with no solution")
+	}
+
+	// A generated game knows its solution.
+	game = New(4, 4)
+	serial, ok := game.SerializeWithSolution()
+	if !ok {
+		t.Errorf("ERROR: Unable to serialize new game")
+	}
+	game2, ok := Deserialize(serial)
+	if !ok {
+		t.Errorf("ERROR: Unable to deserialize %s", serial)
+	}
+	if !game2.grid.Equal(game.grid) {
+		t.Errorf("ERROR: solution did not round trip for %s", serial)
+	}
+}
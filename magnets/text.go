@@ -0,0 +1,526 @@
+package magnets
+
+// Reading a game from a text layout, like the one in the comment at the top of
+// serialize.go, so that people can type in or paste puzzles from books and
+// email.
+//
+//	 + 2 0 1
+//	  +-----+
+//	 1|+ -| |1
+//	  |-+-+ |
+//	 0|-|#| |1
+//	  | +-+-|
+//	 2|+|- +|1
+//	  +-----+
+//	   1 2 0 -
+//
+// The same layout can be drawn with Unicode box-drawing characters:
+//
+//	 + 2 0 1
+//	  ┌─┬─┬─┐
+//	 1│+ -│ │1
+//	  ├─┼─┤ │
+//	 0│-│■│ │1
+//	  │ ├─┼─┤
+//	 2│+│- +│1
+//	  └─┴───┘
+//	   1 2 0 -
+//
+// Cells are on the odd rows and cols inside the border. Between two cells
+// there is either a border character, or a space if they are the two ends of
+// a domino. A cell with a border on every side is a wall. The clues are
+// optional; a missing clue, or a '.', is unknown. Counts above 9 are written
+// as in the serial format ('a' is 10, and so on), though the row counts may
+// also be written out in full.
+//
+// Cells may hold '+', '-', 'x' (neutral), or a space if they are unknown.
+// Walls may hold a space, '#', '*' or '■'. If every cell is filled in and
+// they solve the game they are taken to be the solution, otherwise they are
+// the guess.
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/erikbryant/magnets/board"
+	"github.com/erikbryant/magnets/common"
+)
+
+var (
+	// borderLine matches the top and bottom edges of the board.
+	borderLine = regexp.MustCompile(`^[+┌└][-─┬┴+]*[+┐┘]$`)
+
+	// textRunes maps the characters that may be in a cell to the runes they
+	// stand for.
+	textRunes = map[rune]rune{
+		' ': common.Empty,
+		'+': common.Positive,
+		'-': common.Negative,
+		'x': common.Neutral,
+		'×': common.Neutral,
+	}
+
+	// wallRunes are the characters that may be in a wall.
+	wallRunes = " #*■"
+)
+
+// isBorder returns true if the character is part of a border.
+func isBorder(r rune) bool {
+	return strings.ContainsRune("|-+─│┌┐└┘├┤┬┴┼", r)
+}
+
+// textCount parses a clue. Blank and '.' are unknown. A single character is
+// read as in the serial format; anything longer must be a number.
+func textCount(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "." {
+		return -1, nil
+	}
+	if len(s) == 1 {
+		// runeToCount does not reject the punctuation between the digits
+		// and the letters.
+		if !strings.ContainsRune("0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ", rune(s[0])) {
+			return -1, fmt.Errorf("bad clue %q", s)
+		}
+		return runeToCount(rune(s[0])), nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return -1, fmt.Errorf("bad clue %q", s)
+	}
+	return n, nil
+}
+
+// textChar returns the character at position i of the line, or "" if the
+// line is too short.
+func textChar(line []rune, i int) string {
+	if i < 0 || i >= len(line) {
+		return ""
+	}
+	return string(line[i])
+}
+
+// Parse reads a game from a text layout, in either ASCII or Unicode.
+func Parse(s string) (Game, error) {
+	var lines [][]rune
+	for _, line := range strings.Split(strings.ReplaceAll(s, "\r", ""), "\n") {
+		lines = append(lines, []rune(strings.TrimRight(line, " \t")))
+	}
+
+	// Find the top and bottom edges.
+	top, bottom := -1, -1
+	for i, line := range lines {
+		if borderLine.MatchString(strings.TrimSpace(string(line))) {
+			if top == -1 {
+				top = i
+			}
+			bottom = i
+		}
+	}
+	if top == -1 || top == bottom {
+		return makeGame(0, 0), fmt.Errorf("cannot find the top and bottom edges of the board")
+	}
+
+	left := len(lines[top]) - len([]rune(strings.TrimLeft(string(lines[top]), " \t")))
+	right := len(lines[top]) - 1
+	if (right-left)%2 != 0 || (bottom-top)%2 != 0 {
+		return makeGame(0, 0), fmt.Errorf("the edges of the board are not an even number of characters apart")
+	}
+	width := (right - left) / 2
+	height := (bottom - top) / 2
+
+	// at returns the character at the given position, relative to the top
+	// left corner of the board, or a space if the line is too short.
+	at := func(row, col int) rune {
+		line := lines[top+row]
+		if left+col >= len(line) {
+			return ' '
+		}
+		return line[left+col]
+	}
+
+	game := makeGame(width, height)
+	if width*height <= 1 {
+		return game, fmt.Errorf("the board is too small")
+	}
+
+	// open returns true if there is no border between the cell and the one
+	// in the given direction.
+	open := func(row, col, dRow, dCol int) bool {
+		if row+dRow < 0 || row+dRow >= height || col+dCol < 0 || col+dCol >= width {
+			return false
+		}
+		return !isBorder(at(2*row+1+dRow, 2*col+1+dCol))
+	}
+
+	// The dominoes.
+	for row := 0; row < height; row++ {
+		for col := 0; col < width; col++ {
+			opens := 0
+			for _, adj := range board.Adjacents {
+				if open(row, col, adj.Row, adj.Col) {
+					opens++
+				}
+			}
+
+			switch {
+			case opens > 1:
+				return game, fmt.Errorf("cell %d, %d is open on more than one side", row, col)
+			case open(row, col, 0, 1):
+				game.frames.Set(row, col, common.Left, false)
+			case open(row, col, 0, -1):
+				game.frames.Set(row, col, common.Right, false)
+			case open(row, col, 1, 0):
+				game.frames.Set(row, col, common.Up, false)
+			case open(row, col, -1, 0):
+				game.frames.Set(row, col, common.Down, false)
+			default:
+				game.frames.Set(row, col, common.Wall, false)
+				game.grid.Set(row, col, common.Wall, false)
+				game.Guess.Set(row, col, common.Wall, false)
+			}
+		}
+	}
+
+	// The cells.
+	filled := true
+	cells := makeGame(width, height).Guess
+	for row := 0; row < height; row++ {
+		for col := 0; col < width; col++ {
+			r := at(2*row+1, 2*col+1)
+			if game.frames.Get(row, col, false) == common.Wall {
+				if !strings.ContainsRune(wallRunes, r) {
+					return game, fmt.Errorf("unexpected '%c' in the wall at %d, %d", r, row, col)
+				}
+				cells.Set(row, col, common.Wall, false)
+				continue
+			}
+			cell, ok := textRunes[r]
+			if !ok {
+				return game, fmt.Errorf("unexpected '%c' at %d, %d", r, row, col)
+			}
+			if cell == common.Empty {
+				filled = false
+			}
+			cells.Set(row, col, cell, false)
+		}
+	}
+
+	// The clues.
+	var err error
+	for col := 0; col < width; col++ {
+		game.colPos[col], game.colNeg[col] = -1, -1
+		if top > 0 {
+			game.colPos[col], err = textCount(textChar(lines[top-1], left+2*col+1))
+			if err != nil {
+				return game, err
+			}
+		}
+		if bottom < len(lines)-1 {
+			game.colNeg[col], err = textCount(textChar(lines[bottom+1], left+2*col+1))
+			if err != nil {
+				return game, err
+			}
+		}
+	}
+	for row := 0; row < height; row++ {
+		line := lines[top+2*row+1]
+		if len(line) < left {
+			return game, fmt.Errorf("row %d is too short", row)
+		}
+		game.rowPos[row], err = textCount(string(line[:left]))
+		if err != nil {
+			return game, err
+		}
+		game.rowNeg[row] = -1
+		if len(line) > right+1 {
+			game.rowNeg[row], err = textCount(string(line[right+1:]))
+			if err != nil {
+				return game, err
+			}
+		}
+	}
+
+	if !game.Valid() {
+		return game, fmt.Errorf("invalid domino layout")
+	}
+
+	// Filled in cells that solve the game are its solution.
+	if filled && game.solves(cells) {
+		game.grid = cells
+		return game, nil
+	}
+
+	for cell := range cells.Cells() {
+		row, col := cell.Unpack()
+		game.Guess.Set(row, col, cells.Get(row, col, false), false)
+	}
+
+	return game, nil
+}
+
+// Style is a way of drawing a game as text.
+type Style int
+
+const (
+	// ASCII draws with plain ASCII characters.
+	ASCII Style = iota
+	// Unicode draws the borders with box-drawing characters.
+	Unicode
+	// ANSI is Unicode with red positive and blue negative poles, for
+	// terminals.
+	ANSI
+)
+
+// Panel is one of the boards Fprint can draw.
+type Panel int
+
+const (
+	// PuzzlePanel is the puzzle, with just the walls filled in.
+	PuzzlePanel Panel = iota
+	// SolutionPanel is the solution, if the game knows it.
+	SolutionPanel
+	// GuessPanel is the current guess.
+	GuessPanel
+)
+
+var (
+	// boxRunes are the Unicode corners, indexed by which of the up, down,
+	// left and right arms they have (1, 2, 4 and 8).
+	boxRunes = []rune(" ╵╷│╴┘┐┤╶└┌├─┴┬┼")
+
+	panelNames = map[Panel]string{
+		PuzzlePanel:   "Puzzle",
+		SolutionPanel: "Solution",
+		GuessPanel:    "Guess",
+	}
+)
+
+// textCell returns the characters that draw a cell in the given style.
+func textCell(r rune, style Style) string {
+	switch r {
+	case common.Positive:
+		if style == ANSI {
+			return "\x1b[31m+\x1b[0m"
+		}
+		return "+"
+	case common.Negative:
+		if style == ANSI {
+			return "\x1b[34m-\x1b[0m"
+		}
+		return "-"
+	case common.Neutral:
+		if style == ASCII {
+			return "x"
+		}
+		return "×"
+	case common.Wall:
+		if style == ASCII {
+			return "#"
+		}
+		return "■"
+	}
+	return " "
+}
+
+// visibleLen returns the number of characters in s that take up space on
+// the screen, skipping ANSI escape sequences.
+func visibleLen(s string) int {
+	n := 0
+	escape := false
+	for _, r := range s {
+		switch {
+		case r == '\x1b':
+			escape = true
+		case escape:
+			if r == 'm' {
+				escape = false
+			}
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// textPanel draws one board in the layout that Parse reads, returning its
+// lines.
+func (game *Game) textPanel(name string, cells board.Board, style Style) []string {
+	width := game.frames.Width()
+	height := game.frames.Height()
+
+	// sameDomino returns true if the two cells are the ends of one domino.
+	sameDomino := func(row1, col1, row2, col2 int) bool {
+		if row1 < 0 || row1 >= height || col1 < 0 || col1 >= width {
+			return false
+		}
+		if row2 < 0 || row2 >= height || col2 < 0 || col2 >= width {
+			return false
+		}
+		if game.frames.Get(row1, col1, false) == common.Wall {
+			return false
+		}
+		rowEnd, colEnd := game.GetFrameEnd(row1, col1)
+		return rowEnd == row2 && colEnd == col2
+	}
+
+	// vertical returns true if there is a border to the left of the cell.
+	vertical := func(row, col int) bool {
+		return row >= 0 && row < height && !sameDomino(row, col-1, row, col)
+	}
+	// horizontal returns true if there is a border above the cell.
+	horizontal := func(row, col int) bool {
+		return col >= 0 && col < width && !sameDomino(row-1, col, row, col)
+	}
+
+	corner := func(row, col int) rune {
+		if style == ASCII {
+			return '+'
+		}
+		arms := 0
+		if vertical(row-1, col) {
+			arms |= 1
+		}
+		if vertical(row, col) {
+			arms |= 2
+		}
+		if horizontal(row, col-1) {
+			arms |= 4
+		}
+		if horizontal(row, col) {
+			arms |= 8
+		}
+		return boxRunes[arms]
+	}
+	bar, dash := "|", "-"
+	if style != ASCII {
+		bar, dash = "│", "─"
+	}
+
+	count := func(n int) string {
+		if n < 0 {
+			return "."
+		}
+		return fmt.Sprint(n)
+	}
+
+	// The row clues on the left set the margin.
+	margin := 1
+	for row := 0; row < height; row++ {
+		margin = max(margin, len(count(game.CountRow(row, common.Positive))))
+	}
+	margin++
+	pad := strings.Repeat(" ", margin)
+
+	lines := []string{name}
+
+	line := strings.Repeat(" ", margin-1) + "+"
+	for col := 0; col < width; col++ {
+		line += " " + string(countToRune(game.CountCol(col, common.Positive)))
+	}
+	lines = append(lines, line)
+
+	for row := 0; row <= height; row++ {
+		// The border above the row.
+		line := pad
+		for col := 0; col < width; col++ {
+			line += string(corner(row, col))
+			if horizontal(row, col) {
+				line += dash
+			} else {
+				line += " "
+			}
+		}
+		line += string(corner(row, width))
+		lines = append(lines, line)
+
+		if row == height {
+			break
+		}
+
+		// The row itself.
+		line = fmt.Sprintf("%*s", margin, count(game.CountRow(row, common.Positive)))
+		for col := 0; col < width; col++ {
+			if vertical(row, col) {
+				line += bar
+			} else {
+				line += " "
+			}
+			line += textCell(cells.Get(row, col, false), style)
+		}
+		line += bar + count(game.CountRow(row, common.Negative))
+		lines = append(lines, line)
+	}
+
+	line = pad
+	for col := 0; col < width; col++ {
+		line += " " + string(countToRune(game.CountCol(col, common.Negative)))
+	}
+	lines = append(lines, line+" -")
+
+	return lines
+}
+
+// Fprint draws the game as text, in the layout Parse reads, with the given
+// panels side by side. If no panels are given it draws the puzzle, the
+// solution (if the game knows it) and the guess.
+func (game *Game) Fprint(w io.Writer, style Style, panels ...Panel) error {
+	if len(panels) == 0 {
+		panels = []Panel{PuzzlePanel, SolutionPanel, GuessPanel}
+		if _, ok := game.Solution(); !ok {
+			panels = []Panel{PuzzlePanel, GuessPanel}
+		}
+	}
+
+	var columns [][]string
+	for _, panel := range panels {
+		var cells board.Board
+		switch panel {
+		case PuzzlePanel:
+			cells = board.New(game.frames.Width(), game.frames.Height())
+			for cell := range game.frames.Cells(common.Wall) {
+				row, col := cell.Unpack()
+				cells.Set(row, col, common.Wall, false)
+			}
+		case SolutionPanel:
+			var ok bool
+			cells, ok = game.Solution()
+			if !ok {
+				return fmt.Errorf("game does not have a solution")
+			}
+		case GuessPanel:
+			cells = game.Guess
+		default:
+			return fmt.Errorf("unknown panel %d", panel)
+		}
+		columns = append(columns, game.textPanel(panelNames[panel], cells, style))
+	}
+
+	// Every panel has the same number of lines.
+	for i := range columns[0] {
+		line := ""
+		for j, column := range columns {
+			if j > 0 {
+				line += strings.Repeat(" ", 4)
+			}
+			line += column[i]
+			if j < len(columns)-1 {
+				// Pad to the widest line in the panel.
+				widest := 0
+				for _, l := range column {
+					widest = max(widest, visibleLen(l))
+				}
+				line += strings.Repeat(" ", widest-visibleLen(column[i]))
+			}
+		}
+		_, err := fmt.Fprintln(w, strings.TrimRight(line, " "))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
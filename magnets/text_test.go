@@ -0,0 +1,225 @@
+package magnets
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/erikbryant/magnets/common"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected string
+		solved   bool
+	}{
+		{`
+ + 2 0 1
+  +-----+
+ 1|+ -| |1
+  |-+-+ |
+ 0|-|#| |1
+  | +-+-|
+ 2|+|- +|1
+  +-----+
+   1 2 0 -
+`, "3x3:201,102,120,111,LRTT*BBLR", false},
+		{`
+ + 2 0 1
+  ┌─┬─┬─┐
+ 1│+ -│ │1
+  ├─┼─┤ │
+ 0│-│■│ │1
+  │ ├─┼─┤
+ 2│+│- +│1
+  └─┴───┘
+   1 2 0 -
+`, "3x3:201,102,120,111,LRTT*BBLR", false},
+		// The solution, with unknown and long clues.
+		{`
+   2 . 1
+  +-----+
+01|+ -|x|1
+  |-+-+ |
+ .|-|#|x|1
+  | +-+-|
+ 2|+|- +|1
+  +-----+
+   1 2 0
+`, "3x3:2.1,1.2,120,111,LRTT*BBLR", true},
+		// No clues at all.
+		{`
++-+-+
+|   |
++---+
+`, "2x1:..,.,..,.,LR", false},
+	}
+
+	for _, testCase := range testCases {
+		game, err := Parse(testCase.text)
+		if err != nil {
+			t.Errorf("ERROR: Unable to parse %s: %s", testCase.text, err)
+			continue
+		}
+		serial, _ := game.Serialize()
+		if serial != testCase.expected {
+			t.Errorf("ERROR: For %s expected %s got %s", testCase.text, testCase.expected, serial)
+		}
+		_, solved := game.Solution()
+		if solved != testCase.solved {
+			t.Errorf("ERROR: For %s expected solution %t got %t", testCase.text, testCase.solved, solved)
+		}
+	}
+
+	// A partial solution goes into the guess.
+	game, _ := Parse(testCases[0].text)
+	if game.Guess.Get(0, 0, false) != common.Positive || game.Guess.Get(1, 0, false) != common.Negative || game.Guess.Get(0, 2, false) != common.Empty {
+		t.Errorf("ERROR: Unexpected guess %v", game.Guess)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	testCases := []string{
+		// No board.
+		"",
+		"hello",
+		// No bottom edge.
+		`+---+
+|   |`,
+		// Edges an odd distance apart.
+		`+----+
+|    |
++----+`,
+		// A cell open on two sides.
+		`+-----+
+|     |
+|-+-+ |
+|   | |
++-----+`,
+		// A bad cell.
+		`+---+
+|? ?|
++---+`,
+		// A bad clue.
+		`  ? 1
+ +-+-+
+1|   |1
+ +---+
+  1 1`,
+	}
+
+	for _, testCase := range testCases {
+		_, err := Parse(testCase)
+		if err == nil {
+			t.Errorf("ERROR: Expected an error parsing %s", testCase)
+		}
+	}
+}
+
+func TestFprint(t *testing.T) {
+	game, ok := Deserialize("3x3:2.1,102,120,111,LRTT*BBLR,+#--")
+	if !ok {
+		t.Fatalf("ERROR: Unable to deserialize game")
+	}
+	game.Guess.Set(0, 0, common.Positive, false)
+
+	testCases := []struct {
+		style    Style
+		expected string
+	}{
+		{ASCII, `Puzzle        Solution      Guess
+ + 2 . 1       + 2 . 1       + 2 . 1
+  +-+-+-+       +-+-+-+       +-+-+-+
+ 1|   | |1     1|+ -|x|1     1|+  | |1
+  +-+-+ +       +-+-+ +       +-+-+ +
+ 0| |#| |1     0|-|#|x|1     0| |#| |1
+  + +-+-+       + +-+-+       + +-+-+
+ 2| |   |1     2|+|- +|1     2| |   |1
+  +-+-+-+       +-+-+-+       +-+-+-+
+   1 2 0 -       1 2 0 -       1 2 0 -
+`},
+		{Unicode, `Puzzle        Solution      Guess
+ + 2 . 1       + 2 . 1       + 2 . 1
+  ┌───┬─┐       ┌───┬─┐       ┌───┬─┐
+ 1│   │ │1     1│+ -│×│1     1│+  │ │1
+  ├─┬─┤ │       ├─┬─┤ │       ├─┬─┤ │
+ 0│ │■│ │1     0│-│■│×│1     0│ │■│ │1
+  │ ├─┴─┤       │ ├─┴─┤       │ ├─┴─┤
+ 2│ │   │1     2│+│- +│1     2│ │   │1
+  └─┴───┘       └─┴───┘       └─┴───┘
+   1 2 0 -       1 2 0 -       1 2 0 -
+`},
+	}
+
+	for _, testCase := range testCases {
+		var sb strings.Builder
+		err := game.Fprint(&sb, testCase.style)
+		if err != nil {
+			t.Errorf("ERROR: Fprint failed: %s", err)
+		}
+		if sb.String() != testCase.expected {
+			t.Errorf("ERROR: For style %d expected\n%s\ngot\n%s", testCase.style, testCase.expected, sb.String())
+		}
+	}
+
+	// ANSI colors the poles, but otherwise looks like Unicode.
+	var sb strings.Builder
+	game.Fprint(&sb, ANSI, GuessPanel)
+	if !strings.Contains(sb.String(), "\x1b[31m+\x1b[0m") {
+		t.Errorf("ERROR: Expected a red '+' in\n%s", sb.String())
+	}
+
+	// A game with no solution has no solution panel.
+	game2, _ := Deserialize("3x3:201,102,120,111,LRTT*BBLR")
+	err := game2.Fprint(&sb, ASCII, SolutionPanel)
+	if err == nil {
+		t.Errorf("ERROR: Expected an error printing a missing solution")
+	}
+}
+
+func TestFprintParse(t *testing.T) {
+	serials := []string{
+		"3x3:2.1,102,120,111,LRTT*BBLR,+#--",
+		"5x4:12222,2322,12222,3222,TLRTTBTTBBTBBLRBLRLR,-++--+#+--",
+		"2x10:25,1101011101,34,1110011011,LRLRTTBBLRTTBBTTBBLR",
+	}
+
+	for _, serial := range serials {
+		game, ok := Deserialize(serial)
+		if !ok {
+			t.Fatalf("ERROR: Unable to deserialize %s", serial)
+		}
+		game.Guess.Set(0, 0, common.Positive, false)
+		expected, _ := game.Serialize()
+
+		for _, style := range []Style{ASCII, Unicode} {
+			var sb strings.Builder
+			game.Fprint(&sb, style, GuessPanel)
+			parsed, err := Parse(sb.String())
+			if err != nil {
+				t.Errorf("ERROR: Unable to parse\n%s: %s", sb.String(), err)
+				continue
+			}
+			answer, _ := parsed.Serialize()
+			if answer != expected || !parsed.Guess.Equal(game.Guess) {
+				t.Errorf("ERROR: For %s expected %s got %s", serial, expected, answer)
+			}
+
+			if _, ok := game.Solution(); !ok {
+				continue
+			}
+			sb.Reset()
+			game.Fprint(&sb, style, SolutionPanel)
+			parsed, err = Parse(sb.String())
+			if err != nil {
+				t.Errorf("ERROR: Unable to parse\n%s: %s", sb.String(), err)
+				continue
+			}
+			expected, _ := game.SerializeWithSolution()
+			answer, _ = parsed.SerializeWithSolution()
+			if answer != expected {
+				t.Errorf("ERROR: Expected %s got %s", expected, answer)
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package magnets
+
+import (
+	"github.com/erikbryant/magnets/board"
+	"github.com/erikbryant/magnets/common"
+)
+
+// Transform returns a new game that is this one rotated or mirrored. The
+// frames, the solution, the guess and the counts all move with the cells.
+func (game *Game) Transform(t board.Transform) Game {
+	width, height := game.grid.Width(), game.grid.Height()
+	g := makeGame(t.Size(width, height))
+
+	g.grid = game.grid.Transform(t)
+	g.Guess = game.Guess.Transform(t)
+
+	// The frame runes point at the other end of the domino, so they are
+	// worked out again from where the two ends land.
+	for cell := range game.frames.Cells() {
+		row, col := cell.Unpack()
+		r, c := t.Map(row, col, width, height)
+		if game.frames.Get(row, col, false) == common.Wall {
+			g.frames.Set(r, c, common.Wall, false)
+			continue
+		}
+		rowEnd, colEnd := game.GetFrameEnd(row, col)
+		rEnd, cEnd := t.Map(rowEnd, colEnd, width, height)
+		switch {
+		case rEnd > r:
+			g.frames.Set(r, c, common.Up, false)
+		case rEnd < r:
+			g.frames.Set(r, c, common.Down, false)
+		case cEnd > c:
+			g.frames.Set(r, c, common.Left, false)
+		default:
+			g.frames.Set(r, c, common.Right, false)
+		}
+	}
+
+	// Each new line is an old row or column. Find which by mapping its
+	// first cell back.
+	inverse := t.Inverse()
+	newWidth, newHeight := g.grid.Width(), g.grid.Height()
+	for row := 0; row < newHeight; row++ {
+		r, c := inverse.Map(row, 0, newWidth, newHeight)
+		if t.Transposes() {
+			g.rowPos[row], g.rowNeg[row] = game.colPos[c], game.colNeg[c]
+		} else {
+			g.rowPos[row], g.rowNeg[row] = game.rowPos[r], game.rowNeg[r]
+		}
+	}
+	for col := 0; col < newWidth; col++ {
+		r, c := inverse.Map(0, col, newWidth, newHeight)
+		if t.Transposes() {
+			g.colPos[col], g.colNeg[col] = game.rowPos[r], game.rowNeg[r]
+		} else {
+			g.colPos[col], g.colNeg[col] = game.colPos[c], game.colNeg[c]
+		}
+	}
+
+	return g
+}
+
+// SwapPolarity returns a new game with + and - swapped everywhere, including
+// the counts.
+func (game *Game) SwapPolarity() Game {
+	g := game.Clone()
+
+	for cell := range g.grid.Cells() {
+		row, col := cell.Unpack()
+		g.grid.Set(row, col, common.Negate(g.grid.Get(row, col, false)), false)
+		g.Guess.Set(row, col, common.Negate(g.Guess.Get(row, col, false)), false)
+	}
+	g.rowPos, g.rowNeg = g.rowNeg, g.rowPos
+	g.colPos, g.colNeg = g.colNeg, g.colPos
+
+	return g
+}
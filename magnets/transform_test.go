@@ -0,0 +1,82 @@
+package magnets
+
+import (
+	"testing"
+
+	"github.com/erikbryant/magnets/board"
+)
+
+func TestTransform(t *testing.T) {
+	testCases := []struct {
+		t        board.Transform
+		expected string
+	}{
+		{board.Identity, "3x3:201,102,120,111,LRTT*BBLR,+#--"},
+		{board.MirrorHorizontal, "3x3:102,102,021,111,TLRB*TLRB,#--+"},
+		{board.MirrorVertical, "3x3:201,201,120,111,TLRB*TLRB,+-#+"},
+		{board.Transpose, "3x3:102,201,111,120,TLRB*TLRB,+--#"},
+	}
+
+	game, ok := Deserialize("3x3:201,102,120,111,LRTT*BBLR,+#--")
+	if !ok {
+		t.Fatalf("ERROR: Unable to deserialize game")
+	}
+
+	for _, testCase := range testCases {
+		g := game.Transform(testCase.t)
+		answer, ok := g.SerializeWithSolution()
+		if !ok {
+			t.Errorf("ERROR: Unable to serialize %s", testCase.t)
+		}
+		if answer != testCase.expected {
+			t.Errorf("ERROR: For %s expected %s got %s", testCase.t, testCase.expected, answer)
+		}
+	}
+
+	// The transformed games are new; changing them leaves the original
+	// alone.
+	g := game.Transform(board.Identity)
+	g.Guess.Set(0, 0, '+', false)
+	if game.Guess.Get(0, 0, false) != ' ' {
+		t.Errorf("ERROR: Transforming the game shares its guess")
+	}
+}
+
+func TestTransformCorpus(t *testing.T) {
+	for _, game := range loadGames(t, "../solver/testcases_solve.txt", 50) {
+		serial, _ := game.Serialize()
+		for _, tr := range board.Transforms {
+			g := game.Transform(tr)
+			if !g.Valid() {
+				t.Errorf("ERROR: %s of %s is not valid", tr, serial)
+			}
+
+			// The inverse undoes it.
+			back := g.Transform(tr.Inverse())
+			answer, _ := back.Serialize()
+			if answer != serial {
+				t.Errorf("ERROR: For %s expected %s got %s", tr, serial, answer)
+			}
+		}
+
+		// Four quarter turns are the identity.
+		g := game
+		for range 4 {
+			g = g.Transform(board.Rotate90)
+		}
+		answer, _ := g.Serialize()
+		if answer != serial {
+			t.Errorf("ERROR: Expected %s got %s", serial, answer)
+		}
+	}
+}
+
+func TestSwapPolarity(t *testing.T) {
+	game, _ := Deserialize("3x3:201,102,120,111,LRTT*BBLR,+#--")
+	g := game.SwapPolarity()
+	expected := "3x3:120,111,201,102,LRTT*BBLR,-#++"
+	answer, _ := g.SerializeWithSolution()
+	if answer != expected {
+		t.Errorf("ERROR: Expected %s got %s", expected, answer)
+	}
+}
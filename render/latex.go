@@ -0,0 +1,217 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/erikbryant/magnets/board"
+	"github.com/erikbryant/magnets/common"
+	"github.com/erikbryant/magnets/magnets"
+)
+
+// BookEntry is one puzzle in a book.
+type BookEntry struct {
+	// ID is printed under the puzzle, e.g., its serial form.
+	ID string
+	// Difficulty is printed next to the puzzle number. It may be blank.
+	Difficulty string
+	Game       magnets.Game
+	// Solution is used for the answer key. If it is empty, the game's own
+	// solution is used.
+	Solution board.Board
+}
+
+// BookOptions controls how a book is laid out.
+type BookOptions struct {
+	// Title is printed on the first page. It may be blank.
+	Title string
+	// PerPage is how many puzzles go on each page, in two columns. If zero,
+	// 4 is used.
+	PerPage int
+}
+
+// latexEscape escapes the characters that LaTeX treats specially.
+func latexEscape(s string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\textbackslash{}`,
+		`#`, `\#`,
+		`$`, `\$`,
+		`%`, `\%`,
+		`&`, `\&`,
+		`_`, `\_`,
+		`{`, `\{`,
+		`}`, `\}`,
+		`~`, `\textasciitilde{}`,
+		`^`, `\textasciicircum{}`,
+	)
+	return replacer.Replace(s)
+}
+
+// tikz draws the game as a tikzpicture, one unit per cell, with get giving
+// what to draw in each cell.
+func tikz(sb *strings.Builder, game magnets.Game, get func(row, col int) rune) {
+	width := game.Guess.Width()
+	height := game.Guess.Height()
+
+	// TikZ's y axis points up, so rows count down from zero. Each point is
+	// the center of a cell.
+	at := func(row, col int) string {
+		return fmt.Sprintf("(%.1f,%.1f)", float64(col)+0.5, -float64(row)-0.5)
+	}
+
+	sb.WriteString("\\begin{tikzpicture}\n")
+
+	// The clues.
+	fmt.Fprintf(sb, "\\node at %s {$+$};\n", at(-1, -1))
+	fmt.Fprintf(sb, "\\node at %s {$-$};\n", at(height, width))
+	for col := 0; col < width; col++ {
+		if n := game.CountCol(col, common.Positive); n >= 0 {
+			fmt.Fprintf(sb, "\\node at %s {%d};\n", at(-1, col), n)
+		}
+		if n := game.CountCol(col, common.Negative); n >= 0 {
+			fmt.Fprintf(sb, "\\node at %s {%d};\n", at(height, col), n)
+		}
+	}
+	for row := 0; row < height; row++ {
+		if n := game.CountRow(row, common.Positive); n >= 0 {
+			fmt.Fprintf(sb, "\\node at %s {%d};\n", at(row, -1), n)
+		}
+		if n := game.CountRow(row, common.Negative); n >= 0 {
+			fmt.Fprintf(sb, "\\node at %s {%d};\n", at(row, width), n)
+		}
+	}
+
+	// The cells.
+	for row := 0; row < height; row++ {
+		for col := 0; col < width; col++ {
+			switch get(row, col) {
+			case common.Wall:
+				fmt.Fprintf(sb, "\\fill[gray] (%d,%d) rectangle (%d,%d);\n", col, -row, col+1, -row-1)
+			case common.Neutral:
+				fmt.Fprintf(sb, "\\fill[lightgray] (%.2f,%.2f) rectangle (%.2f,%.2f);\n",
+					float64(col)+0.15, -float64(row)-0.15, float64(col)+0.85, -float64(row)-0.85)
+			case common.Positive:
+				fmt.Fprintf(sb, "\\node at %s {$+$};\n", at(row, col))
+			case common.Negative:
+				fmt.Fprintf(sb, "\\node at %s {$-$};\n", at(row, col))
+			}
+		}
+	}
+
+	// The dominoes.
+	for frame := range game.Frames() {
+		row, col := frame.Unpack()
+		rowEnd, colEnd := game.GetFrameEnd(row, col)
+		fmt.Fprintf(sb, "\\draw[rounded corners=2pt] (%.1f,%.1f) rectangle (%.1f,%.1f);\n",
+			float64(col)+0.1, -float64(row)-0.1, float64(colEnd)+0.9, -float64(rowEnd)-0.9)
+	}
+
+	// The edge of the board.
+	fmt.Fprintf(sb, "\\draw[thick] (0,0) rectangle (%d,%d);\n", width, -height)
+
+	sb.WriteString("\\end{tikzpicture}\n")
+}
+
+// section writes a run of puzzles, PerPage to a page, in two columns. Each page
+// starts in the left column, and the pictures are scaled so that a page's rows
+// of puzzles fit on it.
+func section(sb *strings.Builder, heading string, entries []BookEntry, perPage int, draw func(i int) (string, error)) error {
+	fmt.Fprintf(sb, "\\section*{%s}\n", latexEscape(heading))
+
+	// Leave room on each row for the label and the ID.
+	rows := (perPage + 1) / 2
+	height := 0.75/float64(rows) - 0.025
+
+	for i, entry := range entries {
+		// The position on the page.
+		j := i % perPage
+		if i > 0 && j == 0 {
+			sb.WriteString("\\newpage\n")
+		}
+
+		picture, err := draw(i)
+		if err != nil {
+			return fmt.Errorf("puzzle %d (%s): %w", i+1, entry.ID, err)
+		}
+
+		label := fmt.Sprintf("%d", i+1)
+		if entry.Difficulty != "" {
+			label += " (" + entry.Difficulty + ")"
+		}
+
+		sb.WriteString("\\begin{minipage}[t]{0.48\\linewidth}\n\\centering\n")
+		fmt.Fprintf(sb, "\\textbf{%s}\\par\\medskip\n", latexEscape(label))
+		fmt.Fprintf(sb, "\\begin{adjustbox}{max width=\\linewidth, max totalheight=%.3f\\textheight}\n", height)
+		sb.WriteString(picture)
+		sb.WriteString("\\end{adjustbox}\\par\\smallskip\n")
+		// Long IDs break anywhere rather than run into the next column.
+		fmt.Fprintf(sb, "{\\tiny\\ttfamily\\seqsplit{%s}}\n", latexEscape(entry.ID))
+		sb.WriteString("\\end{minipage}")
+		if j%2 == 0 && j+1 < perPage && i+1 < len(entries) {
+			sb.WriteString("\\hfill\n")
+		} else {
+			sb.WriteString("\n\n\\bigskip\n")
+		}
+	}
+	sb.WriteString("\n\\newpage\n")
+
+	return nil
+}
+
+// Book writes a LaTeX document with every puzzle, followed by an answer key.
+// It needs the tikz, adjustbox and seqsplit packages.
+func Book(w io.Writer, entries []BookEntry, opts BookOptions) error {
+	perPage := opts.PerPage
+	if perPage <= 0 {
+		perPage = 4
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("\\documentclass{article}\n")
+	sb.WriteString("\\usepackage[margin=2cm]{geometry}\n")
+	sb.WriteString("\\usepackage{tikz}\n")
+	sb.WriteString("\\usepackage{adjustbox}\n")
+	sb.WriteString("\\usepackage{seqsplit}\n")
+	sb.WriteString("\\setlength{\\parindent}{0pt}\n")
+	sb.WriteString("\\begin{document}\n")
+	if opts.Title != "" {
+		fmt.Fprintf(&sb, "\\begin{center}\\LARGE %s\\end{center}\n", latexEscape(opts.Title))
+	}
+
+	err := section(&sb, "Puzzles", entries, perPage, func(i int) (string, error) {
+		get, err := cells(entries[i].Game, ShowPuzzle)
+		if err != nil {
+			return "", err
+		}
+		var picture strings.Builder
+		tikz(&picture, entries[i].Game, get)
+		return picture.String(), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = section(&sb, "Answers", entries, perPage, func(i int) (string, error) {
+		solution := entries[i].Solution
+		if solution.Width() == 0 {
+			var ok bool
+			solution, ok = entries[i].Game.Solution()
+			if !ok {
+				return "", fmt.Errorf("no solution for the answer key")
+			}
+		}
+		var picture strings.Builder
+		tikz(&picture, entries[i].Game, func(row, col int) rune { return solution.Get(row, col, false) })
+		return picture.String(), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sb.WriteString("\\end{document}\n")
+
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,167 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/erikbryant/magnets/magnets"
+)
+
+func TestLatexEscape(t *testing.T) {
+	testCases := []struct {
+		s        string
+		expected string
+	}{
+		{"3x3:201,102,120,111,LRTT*BBLR", "3x3:201,102,120,111,LRTT*BBLR"},
+		{"3x3:201,102,120,111,LRTT*BBLR,+#--", `3x3:201,102,120,111,LRTT*BBLR,+\#--`},
+		{`50% & {x_1}`, `50\% \& \{x\_1\}`},
+	}
+
+	for _, testCase := range testCases {
+		answer := latexEscape(testCase.s)
+		if answer != testCase.expected {
+			t.Errorf("ERROR: For %s expected %s got %s", testCase.s, testCase.expected, answer)
+		}
+	}
+}
+
+func TestBook(t *testing.T) {
+	serials := []string{
+		"3x3:201,102,120,111,LRTT*BBLR,+#--",
+		"5x4:12222,2322,12222,3222,TLRTTBTTBBTBBLRBLRLR,-++--+#+--",
+		"3x3:201,102,120,111,LRTT*BBLR,+#--",
+	}
+
+	var entries []BookEntry
+	for _, serial := range serials {
+		game, ok := magnets.Deserialize(serial)
+		if !ok {
+			t.Fatalf("ERROR: Unable to deserialize %s", serial)
+		}
+		entries = append(entries, BookEntry{ID: serial, Difficulty: "easy", Game: game})
+	}
+
+	var sb strings.Builder
+	err := Book(&sb, entries, BookOptions{Title: "Test", PerPage: 2})
+	if err != nil {
+		t.Fatalf("ERROR: Book failed: %s", err)
+	}
+	book := sb.String()
+
+	counts := []struct {
+		s        string
+		expected int
+	}{
+		{`\begin{document}`, 1},
+		{`\end{document}`, 1},
+		{`\begin{tikzpicture}`, 6},
+		{`\end{tikzpicture}`, 6},
+		// One break within each section, and one after each.
+		{`\newpage`, 4},
+		{`\textbf{3 (easy)}`, 2},
+		{`LRTT*BBLR,+\#--`, 4},
+	}
+	for _, c := range counts {
+		answer := strings.Count(book, c.s)
+		if answer != c.expected {
+			t.Errorf("ERROR: Expected %d of %s got %d", c.expected, c.s, answer)
+		}
+	}
+
+	// A game with no solution cannot go in the answer key.
+	game, _ := magnets.Deserialize("3x3:201,102,120,111,LRTT*BBLR")
+	err = Book(io.Discard, []BookEntry{{ID: "x", Game: game}}, BookOptions{})
+	if err == nil {
+		t.Errorf("ERROR: Expected an error for a game with no solution")
+	}
+
+	// Unless the solution is given.
+	solved, _ := magnets.Deserialize(serials[0])
+	solution, _ := solved.Solution()
+	err = Book(io.Discard, []BookEntry{{ID: "x", Game: game, Solution: solution}}, BookOptions{})
+	if err != nil {
+		t.Errorf("ERROR: Book failed: %s", err)
+	}
+}
+
+func TestBookLayout(t *testing.T) {
+	game, ok := magnets.Deserialize("3x3:201,102,120,111,LRTT*BBLR,+#--")
+	if !ok {
+		t.Fatalf("ERROR: Unable to deserialize game")
+	}
+	// Long enough that it has to break to fit in its column.
+	id := strings.Repeat("LRTT*BBLR", 20)
+
+	testCases := []struct {
+		perPage int
+		puzzles int
+	}{
+		{3, 7},
+		{5, 5},
+		{1, 3},
+		{8, 3},
+		{10, 21},
+	}
+
+	for _, testCase := range testCases {
+		var entries []BookEntry
+		for range testCase.puzzles {
+			entries = append(entries, BookEntry{ID: id, Game: game})
+		}
+
+		var sb strings.Builder
+		err := Book(&sb, entries, BookOptions{PerPage: testCase.perPage})
+		if err != nil {
+			t.Fatalf("ERROR: Book failed: %s", err)
+		}
+		book := sb.String()
+
+		start := strings.Index(book, `\section*{Puzzles}`)
+		end := strings.Index(book, `\section*{Answers}`)
+		pages := strings.Split(strings.TrimSpace(book[start:end]), `\newpage`)
+		pages = pages[:len(pages)-1]
+
+		expectedPages := (testCase.puzzles + testCase.perPage - 1) / testCase.perPage
+		if len(pages) != expectedPages {
+			t.Errorf("ERROR: For %d per page expected %d pages got %d", testCase.perPage, expectedPages, len(pages))
+			continue
+		}
+
+		// Every page starts in the left column. A puzzle is followed by
+		// \hfill if it is on the left with another to its right, and
+		// ends the row otherwise.
+		for p, page := range pages {
+			onPage := min(testCase.perPage, testCase.puzzles-p*testCase.perPage)
+			parts := strings.Split(page, `\end{minipage}`)[1:]
+			if len(parts) != onPage {
+				t.Errorf("ERROR: For %d per page expected %d puzzles on page %d got %d", testCase.perPage, onPage, p+1, len(parts))
+				continue
+			}
+			for j, part := range parts {
+				left := j%2 == 0 && j+1 < onPage
+				if strings.HasPrefix(part, `\hfill`) != left {
+					t.Errorf("ERROR: For %d per page puzzle %d on page %d: expected left column %t", testCase.perPage, j+1, p+1, left)
+				}
+			}
+		}
+
+		// The rows of pictures fit on the page.
+		var height float64
+		i := strings.Index(book, "max totalheight=")
+		_, err = fmt.Sscanf(book[i:], "max totalheight=%f", &height)
+		if err != nil {
+			t.Fatalf("ERROR: No picture height in the book: %s", err)
+		}
+		rows := (testCase.perPage + 1) / 2
+		if height <= 0 || float64(rows)*height > 0.8 {
+			t.Errorf("ERROR: For %d per page %d rows of %.3f do not fit", testCase.perPage, rows, height)
+		}
+
+		// The IDs can break.
+		if strings.Count(book, `\seqsplit{`+id+`}`) != 2*testCase.puzzles || strings.Contains(book, `\texttt{`) {
+			t.Errorf("ERROR: For %d per page expected every ID in \\seqsplit", testCase.perPage)
+		}
+	}
+}
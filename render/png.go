@@ -0,0 +1,158 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+
+	"github.com/erikbryant/magnets/common"
+	"github.com/erikbryant/magnets/magnets"
+)
+
+var (
+	// font is a 5x7 bitmap font with just the glyphs a game needs.
+	font = map[rune][7]string{
+		'0': {".###.", "#...#", "#..##", "#.#.#", "##..#", "#...#", ".###."},
+		'1': {"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."},
+		'2': {".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"},
+		'3': {"#####", "...#.", "..#..", "...#.", "....#", "#...#", ".###."},
+		'4': {"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+		'5': {"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+		'6': {"..##.", ".#...", "#....", "####.", "#...#", "#...#", ".###."},
+		'7': {"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+		'8': {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+		'9': {".###.", "#...#", "#...#", ".####", "....#", "...#.", ".##.."},
+		'+': {".....", "..#..", "..#..", "#####", "..#..", "..#..", "....."},
+		'-': {".....", ".....", ".....", "#####", ".....", ".....", "....."},
+	}
+
+	white     = color.RGBA{255, 255, 255, 255}
+	black     = color.RGBA{0, 0, 0, 255}
+	dimGray   = color.RGBA{105, 105, 105, 255}
+	lightGray = color.RGBA{211, 211, 211, 255}
+	lightRed  = color.RGBA{255, 192, 192, 255}
+)
+
+// fill paints a rectangle.
+func fill(img draw.Image, x, y, w, h int, c color.Color) {
+	draw.Draw(img, image.Rect(x, y, x+w, y+h), &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+// outline paints the border of a rectangle, thick pixels wide.
+func outline(img draw.Image, x, y, w, h, thick int, c color.Color) {
+	fill(img, x, y, w, thick, c)
+	fill(img, x, y+h-thick, w, thick, c)
+	fill(img, x, y, thick, h, c)
+	fill(img, x+w-thick, y, thick, h, c)
+}
+
+// glyphs paints the string in the bitmap font, centered in the given cell,
+// with each font pixel scale pixels square.
+func (l layout) glyphs(img draw.Image, row, col int, s string, scale int) {
+	if s == "" {
+		return
+	}
+
+	runes := []rune(s)
+	w := (len(runes)*6 - 1) * scale
+	h := 7 * scale
+	x := l.x(col) + (l.size-w)/2
+	y := l.y(row) + (l.size-h)/2
+
+	for _, r := range runes {
+		for gy, line := range font[r] {
+			for gx, pixel := range line {
+				if pixel == '#' {
+					fill(img, x+gx*scale, y+gy*scale, scale, scale, black)
+				}
+			}
+		}
+		x += 6 * scale
+	}
+}
+
+// Image draws the game as an image.
+func Image(game magnets.Game, opts Options) (image.Image, error) {
+	get, err := cells(game, opts.Show)
+	if err != nil {
+		return nil, err
+	}
+
+	l := layout{
+		size:   opts.CellSize,
+		width:  game.Guess.Width(),
+		height: game.Guess.Height(),
+	}
+	if l.size <= 0 {
+		l.size = 40
+	}
+	thick := max(l.size/20, 1)
+	clueScale := max(l.size/16, 1)
+	magnetScale := max(l.size/10, 1)
+
+	img := image.NewRGBA(image.Rect(0, 0, l.x(l.width+1), l.y(l.height+1)))
+	fill(img, 0, 0, img.Bounds().Dx(), img.Bounds().Dy(), white)
+
+	// The clues.
+	l.glyphs(img, -1, -1, "+", clueScale)
+	l.glyphs(img, l.height, l.width, "-", clueScale)
+	for col := 0; col < l.width; col++ {
+		l.glyphs(img, -1, col, count(game.CountCol(col, common.Positive)), clueScale)
+		l.glyphs(img, l.height, col, count(game.CountCol(col, common.Negative)), clueScale)
+	}
+	for row := 0; row < l.height; row++ {
+		l.glyphs(img, row, -1, count(game.CountRow(row, common.Positive)), clueScale)
+		l.glyphs(img, row, l.width, count(game.CountRow(row, common.Negative)), clueScale)
+	}
+
+	// The conflicts go underneath everything else in the cells.
+	if opts.Highlight {
+		for _, cell := range game.Conflicts() {
+			row, col := cell.Unpack()
+			fill(img, l.x(col), l.y(row), l.size, l.size, lightRed)
+		}
+	}
+
+	// The cells.
+	for row := 0; row < l.height; row++ {
+		for col := 0; col < l.width; col++ {
+			switch get(row, col) {
+			case common.Wall:
+				fill(img, l.x(col), l.y(row), l.size, l.size, dimGray)
+			case common.Neutral:
+				inset := l.size / 8
+				fill(img, l.x(col)+inset, l.y(row)+inset, l.size-2*inset, l.size-2*inset, lightGray)
+			case common.Positive:
+				l.glyphs(img, row, col, "+", magnetScale)
+			case common.Negative:
+				l.glyphs(img, row, col, "-", magnetScale)
+			}
+		}
+	}
+
+	// The dominoes.
+	for frame := range game.Frames() {
+		row, col := frame.Unpack()
+		rowEnd, colEnd := game.GetFrameEnd(row, col)
+		inset := l.size / 10
+		outline(img, l.x(col)+inset, l.y(row)+inset,
+			(colEnd-col+1)*l.size-2*inset, (rowEnd-row+1)*l.size-2*inset,
+			thick, black)
+	}
+
+	// The edge of the board.
+	outline(img, l.x(0), l.y(0), l.width*l.size, l.height*l.size, thick, black)
+
+	return img, nil
+}
+
+// PNG writes the game as a PNG image.
+func PNG(w io.Writer, game magnets.Game, opts Options) error {
+	img, err := Image(game, opts)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
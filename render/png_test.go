@@ -0,0 +1,68 @@
+package render
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/erikbryant/magnets/common"
+	"github.com/erikbryant/magnets/magnets"
+)
+
+func TestPNG(t *testing.T) {
+	game, ok := magnets.Deserialize("3x3:201,102,120,111,LRTT*BBLR,+#--")
+	if !ok {
+		t.Fatalf("ERROR: Unable to deserialize game")
+	}
+
+	// Like signs next to each other in row 0.
+	game.Guess.Set(0, 0, common.Positive, false)
+	game.Guess.Set(0, 1, common.Positive, false)
+
+	var buf bytes.Buffer
+	err := PNG(&buf, game, Options{CellSize: 20, Show: ShowGuess, Highlight: true})
+	if err != nil {
+		t.Fatalf("ERROR: PNG failed: %s", err)
+	}
+
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("ERROR: Unable to decode PNG: %s", err)
+	}
+
+	testCases := []struct {
+		x, y     int
+		expected color.Color
+	}{
+		// The margin.
+		{1, 1, white},
+		// The wall at 1, 1.
+		{50, 50, dimGray},
+		// The conflict at 0, 2.
+		{63, 23, lightRed},
+		// No conflict at 2, 2.
+		{63, 63, white},
+		// The edge of the board.
+		{20, 50, black},
+	}
+
+	if img.Bounds().Dx() != 100 || img.Bounds().Dy() != 100 {
+		t.Errorf("ERROR: Expected 100x100 got %v", img.Bounds())
+	}
+
+	for _, testCase := range testCases {
+		r1, g1, b1, _ := img.At(testCase.x, testCase.y).RGBA()
+		r2, g2, b2, _ := testCase.expected.RGBA()
+		if r1 != r2 || g1 != g2 || b1 != b2 {
+			t.Errorf("ERROR: At %d, %d expected %v got %v", testCase.x, testCase.y, testCase.expected, img.At(testCase.x, testCase.y))
+		}
+	}
+
+	// There is no solution to show.
+	game, _ = magnets.Deserialize("3x3:201,102,120,111,LRTT*BBLR")
+	_, err = Image(game, Options{Show: ShowSolution})
+	if err == nil {
+		t.Errorf("ERROR: Expected an error showing a missing solution")
+	}
+}
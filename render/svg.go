@@ -0,0 +1,200 @@
+package render
+
+// This package draws games of magnets the way they look in print: the
+// dominoes, the clues around the edges, and, optionally, the magnets.
+//
+// The clues go where Simon Tatham's puzzle puts them. The positive counts run
+// along the top and down the left, the negative counts along the bottom and
+// down the right, with a '+' in the top left corner and a '-' in the bottom
+// right corner.
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/erikbryant/magnets/common"
+	"github.com/erikbryant/magnets/magnets"
+)
+
+// Show is which magnets, if any, to draw in the dominoes.
+type Show int
+
+const (
+	// ShowPuzzle draws just the puzzle, with empty dominoes.
+	ShowPuzzle Show = iota
+	// ShowSolution draws the game's solution.
+	ShowSolution
+	// ShowGuess draws the game's current guess.
+	ShowGuess
+)
+
+// Options controls how a game is drawn.
+type Options struct {
+	// CellSize is the width and height of each cell, in pixels. If zero, 40
+	// is used.
+	CellSize int
+	Show     Show
+	// Highlight shades the cells of the guess that break a rule.
+	Highlight bool
+}
+
+// layout holds the geometry shared by the parts of a drawing.
+type layout struct {
+	size   int
+	width  int
+	height int
+}
+
+// x returns the left edge of the given column. The clues are in columns -1
+// and width.
+func (l layout) x(col int) int {
+	return (col + 1) * l.size
+}
+
+// y returns the top edge of the given row. The clues are in rows -1 and
+// height.
+func (l layout) y(row int) int {
+	return (row + 1) * l.size
+}
+
+// cells returns a function that gives what to draw in each cell.
+func cells(game magnets.Game, show Show) (func(row, col int) rune, error) {
+	switch show {
+	case ShowPuzzle:
+		return func(row, col int) rune {
+			if game.GetFrame(row, col) == common.Wall {
+				return common.Wall
+			}
+			return common.Empty
+		}, nil
+	case ShowSolution:
+		solution, ok := game.Solution()
+		if !ok {
+			return nil, fmt.Errorf("game does not have a solution")
+		}
+		return func(row, col int) rune { return solution.Get(row, col, false) }, nil
+	case ShowGuess:
+		return func(row, col int) rune { return game.Guess.Get(row, col, false) }, nil
+	}
+
+	return nil, fmt.Errorf("unknown show value %d", show)
+}
+
+// count returns the text for a clue, which is blank if the count is unknown.
+func count(n int) string {
+	if n < 0 {
+		return ""
+	}
+	return fmt.Sprint(n)
+}
+
+// text draws centered text in the given cell.
+func (l layout) text(sb *strings.Builder, row, col int, s string) {
+	if s == "" {
+		return
+	}
+	fmt.Fprintf(sb, `<text x="%d" y="%d" font-family="sans-serif" font-size="%d" text-anchor="middle" dominant-baseline="central">%s</text>`+"\n",
+		l.x(col)+l.size/2, l.y(row)+l.size/2, l.size/2, s)
+}
+
+// plus draws a '+' in the given cell.
+func (l layout) plus(sb *strings.Builder, row, col int) {
+	cx := l.x(col) + l.size/2
+	cy := l.y(row) + l.size/2
+	arm := l.size / 4
+	fmt.Fprintf(sb, `<path d="M%d %dH%dM%d %dV%d" stroke="black" stroke-width="%d"/>`+"\n",
+		cx-arm, cy, cx+arm, cx, cy-arm, cy+arm, max(l.size/16, 1))
+}
+
+// minus draws a '-' in the given cell.
+func (l layout) minus(sb *strings.Builder, row, col int) {
+	cx := l.x(col) + l.size/2
+	cy := l.y(row) + l.size/2
+	arm := l.size / 4
+	fmt.Fprintf(sb, `<path d="M%d %dH%d" stroke="black" stroke-width="%d"/>`+"\n",
+		cx-arm, cy, cx+arm, max(l.size/16, 1))
+}
+
+// SVG writes the game as an SVG image.
+func SVG(w io.Writer, game magnets.Game, opts Options) error {
+	get, err := cells(game, opts.Show)
+	if err != nil {
+		return err
+	}
+
+	l := layout{
+		size:   opts.CellSize,
+		width:  game.Guess.Width(),
+		height: game.Guess.Height(),
+	}
+	if l.size <= 0 {
+		l.size = 40
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		l.x(l.width+1), l.y(l.height+1), l.x(l.width+1), l.y(l.height+1))
+	fmt.Fprintf(&sb, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+
+	// The clues.
+	l.plus(&sb, -1, -1)
+	l.minus(&sb, l.height, l.width)
+	for col := 0; col < l.width; col++ {
+		l.text(&sb, -1, col, count(game.CountCol(col, common.Positive)))
+		l.text(&sb, l.height, col, count(game.CountCol(col, common.Negative)))
+	}
+	for row := 0; row < l.height; row++ {
+		l.text(&sb, row, -1, count(game.CountRow(row, common.Positive)))
+		l.text(&sb, row, l.width, count(game.CountRow(row, common.Negative)))
+	}
+
+	// The conflicts go underneath everything else in the cells.
+	if opts.Highlight {
+		for _, cell := range game.Conflicts() {
+			row, col := cell.Unpack()
+			fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="#ffc0c0"/>`+"\n",
+				l.x(col), l.y(row), l.size, l.size)
+		}
+	}
+
+	// The cells.
+	for row := 0; row < l.height; row++ {
+		for col := 0; col < l.width; col++ {
+			switch get(row, col) {
+			case common.Wall:
+				fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="dimgray"/>`+"\n",
+					l.x(col), l.y(row), l.size, l.size)
+			case common.Neutral:
+				inset := l.size / 8
+				fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="lightgray"/>`+"\n",
+					l.x(col)+inset, l.y(row)+inset, l.size-2*inset, l.size-2*inset)
+			case common.Positive:
+				l.plus(&sb, row, col)
+			case common.Negative:
+				l.minus(&sb, row, col)
+			}
+		}
+	}
+
+	// The dominoes.
+	for frame := range game.Frames() {
+		row, col := frame.Unpack()
+		rowEnd, colEnd := game.GetFrameEnd(row, col)
+		inset := l.size / 10
+		fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" rx="%d" fill="none" stroke="black" stroke-width="%d"/>`+"\n",
+			l.x(col)+inset, l.y(row)+inset,
+			(colEnd-col+1)*l.size-2*inset, (rowEnd-row+1)*l.size-2*inset,
+			l.size/8, max(l.size/20, 1))
+	}
+
+	// The edge of the board.
+	fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="none" stroke="black" stroke-width="%d"/>`+"\n",
+		l.x(0), l.y(0), l.width*l.size, l.height*l.size, max(l.size/20, 1))
+
+	sb.WriteString("</svg>\n")
+
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,72 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+
+	"github.com/erikbryant/magnets/magnets"
+)
+
+// elements counts the elements of each kind in an XML document.
+func elements(t *testing.T, doc []byte) map[string]int {
+	counts := map[string]int{}
+	decoder := xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ERROR: invalid XML: %s\n%s", err, doc)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			counts[start.Name.Local]++
+		}
+	}
+	return counts
+}
+
+func TestSVG(t *testing.T) {
+	testCases := []struct {
+		serial string
+		show   Show
+		rects  int
+		paths  int
+		texts  int
+	}{
+		// Background, wall, 4 dominoes, edge. The '+' and '-' corners.
+		{"3x3:201,102,120,111,LRTT*BBLR,+#--", ShowPuzzle, 7, 2, 12},
+		// Plus 2 neutral cells, 3 '+' and 3 '-'.
+		{"3x3:201,102,120,111,LRTT*BBLR,+#--", ShowSolution, 9, 8, 12},
+		// Unknown counts are left blank.
+		{"3x3:2.1,1.2,120,111,LRTT*BBLR", ShowPuzzle, 7, 2, 10},
+	}
+
+	for _, testCase := range testCases {
+		game, ok := magnets.Deserialize(testCase.serial)
+		if !ok {
+			t.Fatalf("ERROR: Unable to deserialize %s", testCase.serial)
+		}
+
+		var buf bytes.Buffer
+		err := SVG(&buf, game, Options{Show: testCase.show})
+		if err != nil {
+			t.Errorf("ERROR: SVG failed for %s: %s", testCase.serial, err)
+			continue
+		}
+
+		counts := elements(t, buf.Bytes())
+		if counts["svg"] != 1 || counts["rect"] != testCase.rects || counts["path"] != testCase.paths || counts["text"] != testCase.texts {
+			t.Errorf("ERROR: For %s %d got %v", testCase.serial, testCase.show, counts)
+		}
+	}
+
+	// There is no solution to show.
+	game, _ := magnets.Deserialize("3x3:201,102,120,111,LRTT*BBLR")
+	err := SVG(io.Discard, game, Options{Show: ShowSolution})
+	if err == nil {
+		t.Errorf("ERROR: Expected an error showing a missing solution")
+	}
+}
@@ -0,0 +1,269 @@
+package sgt
+
+// Reading and writing the reference apps' save files. A save file is a series
+// of records, each a key padded to eight characters, the length of the value
+// in bytes, and the value:
+//
+//	SAVEFILE:41:Simon Tatham's Portable Puzzle Collection
+//	VERSION :1:1
+//	GAME    :7:Magnets
+//	PARAMS  :5:3x3dt
+//	CPARAMS :5:3x3dt
+//	DESC    :25:201,102,120,111,LRTT*BBLR
+//	NSTATES :1:3
+//	STATEPOS:1:3
+//	MOVE    :4:+0,0
+//	MOVE    :4:.2,0
+//
+// Each move after the first state is a MOVE, a SOLVE or a RESTART record.
+// A move is a list of actions separated by ';'. The actions that change the
+// board are a sign and the col,row of one end of a domino: '+' and '-' set
+// that end (and the other end to the opposite sign), '.' makes the domino
+// neutral and ' ' clears it. Other actions, such as 'S' (solved) and the
+// player's '?' notes, do not change the board and are skipped. STATEPOS is
+// the state the player was looking at, counting the first state as 1.
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/erikbryant/magnets/common"
+	"github.com/erikbryant/magnets/magnets"
+)
+
+const (
+	saveMagic = "Simon Tatham's Portable Puzzle Collection"
+	saveGame  = "Magnets"
+)
+
+// record is one key/value pair in a save file.
+type record struct {
+	key   string
+	value string
+}
+
+// readRecords splits a save file into its records.
+func readRecords(r io.Reader) ([]record, error) {
+	br := bufio.NewReader(r)
+	var records []record
+
+	for {
+		// Skip the newlines between records.
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		if b == '\n' || b == '\r' {
+			continue
+		}
+		br.UnreadByte()
+
+		key, err := br.ReadString(':')
+		if err != nil {
+			return records, fmt.Errorf("save file record has no key")
+		}
+		length, err := br.ReadString(':')
+		if err != nil {
+			return records, fmt.Errorf("save file record %s has no length", key)
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(length, ":"))
+		if err != nil || n < 0 {
+			return records, fmt.Errorf("save file record %s has a bad length %q", key, length)
+		}
+		value := make([]byte, n)
+		_, err = io.ReadFull(br, value)
+		if err != nil {
+			return records, fmt.Errorf("save file record %s is short", key)
+		}
+
+		records = append(records, record{
+			key:   strings.TrimSpace(strings.TrimSuffix(key, ":")),
+			value: string(value),
+		})
+	}
+}
+
+// writeRecord writes one key/value pair to a save file.
+func writeRecord(w io.Writer, key, value string) error {
+	_, err := fmt.Fprintf(w, "%-8.8s:%d:%s\n", key, len(value), value)
+	return err
+}
+
+// applyMove makes the changes to the guess that a move describes.
+func applyMove(game *magnets.Game, move string) error {
+	width := game.Guess.Width()
+	height := game.Guess.Height()
+
+	for _, action := range strings.Split(move, ";") {
+		if action == "" {
+			continue
+		}
+		c := action[0]
+		if !strings.ContainsRune("+-. ", rune(c)) {
+			// Notes and flags do not change the board.
+			continue
+		}
+
+		var col, row int
+		_, err := fmt.Sscanf(action[1:], "%d,%d", &col, &row)
+		if err != nil || col < 0 || row < 0 || col >= width || row >= height {
+			return fmt.Errorf("bad move %q", action)
+		}
+		rowEnd, colEnd := game.GetFrameEnd(row, col)
+		if rowEnd == -1 && colEnd == -1 {
+			return fmt.Errorf("move %q is on a wall", action)
+		}
+
+		var r rune
+		switch c {
+		case '+':
+			r = common.Positive
+		case '-':
+			r = common.Negative
+		case '.':
+			r = common.Neutral
+		case ' ':
+			r = common.Empty
+		}
+
+		game.Guess.Set(row, col, r, false)
+		game.Guess.Set(rowEnd, colEnd, common.Negate(r), false)
+	}
+
+	return nil
+}
+
+// ReadSave reads a save file, returning the game with its guess as it was at
+// the saved position.
+func ReadSave(r io.Reader) (magnets.Game, Params, error) {
+	records, err := readRecords(r)
+	if err != nil {
+		return magnets.Game{}, DefaultParams, err
+	}
+	if len(records) == 0 || records[0].key != "SAVEFILE" || records[0].value != saveMagic {
+		return magnets.Game{}, DefaultParams, fmt.Errorf("not a save file")
+	}
+
+	var params, desc string
+	var moves []string
+	nStates, statePos := 0, 0
+
+	for _, rec := range records[1:] {
+		switch rec.key {
+		case "GAME":
+			if rec.value != saveGame {
+				return magnets.Game{}, DefaultParams, fmt.Errorf("save file is for %s, not %s", rec.value, saveGame)
+			}
+		case "PARAMS":
+			params = rec.value
+		case "DESC":
+			desc = rec.value
+		case "NSTATES":
+			nStates, err = strconv.Atoi(rec.value)
+		case "STATEPOS":
+			statePos, err = strconv.Atoi(rec.value)
+		case "MOVE", "SOLVE":
+			moves = append(moves, rec.value)
+		case "RESTART":
+			// Back to the start, but the moves before it are
+			// still in the history.
+			moves = append(moves, "")
+		}
+		if err != nil {
+			return magnets.Game{}, DefaultParams, fmt.Errorf("save file record %s has a bad value %q", rec.key, rec.value)
+		}
+	}
+
+	if params == "" || desc == "" {
+		return magnets.Game{}, DefaultParams, fmt.Errorf("save file has no game")
+	}
+	if nStates != len(moves)+1 || statePos < 1 || statePos > nStates {
+		return magnets.Game{}, DefaultParams, fmt.Errorf("save file has %d states, %d moves and position %d", nStates, len(moves), statePos)
+	}
+
+	game, p, err := ParseID(context.Background(), params+":"+desc)
+	if err != nil {
+		return game, p, err
+	}
+
+	// Replay the moves up to the saved position. A restart clears the
+	// board.
+	for i, move := range moves[:statePos-1] {
+		if move == "" {
+			for cell := range game.Guess.Cells() {
+				row, col := cell.Unpack()
+				if game.Guess.Get(row, col, false) != common.Wall {
+					game.Guess.Set(row, col, common.Empty, false)
+				}
+			}
+			continue
+		}
+		err = applyMove(&game, move)
+		if err != nil {
+			return game, p, fmt.Errorf("move %d: %w", i+1, err)
+		}
+	}
+
+	return game, p, nil
+}
+
+// WriteSave writes the game as a save file, with one move for each domino
+// that is filled in in the guess. The size in p is ignored; the game's own
+// size is used.
+func WriteSave(w io.Writer, game magnets.Game, p Params) error {
+	p.Width = game.Guess.Width()
+	p.Height = game.Guess.Height()
+
+	id, err := ID(game)
+	if err != nil {
+		return err
+	}
+	desc := id[strings.IndexRune(id, ':')+1:]
+
+	var moves []string
+	for frame := range game.Frames() {
+		row, col := frame.Unpack()
+		var c string
+		switch game.Guess.Get(row, col, false) {
+		case common.Positive:
+			c = "+"
+		case common.Negative:
+			c = "-"
+		case common.Neutral:
+			c = "."
+		default:
+			continue
+		}
+		moves = append(moves, fmt.Sprintf("%s%d,%d", c, col, row))
+	}
+
+	records := []record{
+		{"SAVEFILE", saveMagic},
+		{"VERSION", "1"},
+		{"GAME", saveGame},
+		{"PARAMS", p.String()},
+		{"CPARAMS", p.String()},
+		{"DESC", desc},
+		{"NSTATES", strconv.Itoa(len(moves) + 1)},
+		{"STATEPOS", strconv.Itoa(len(moves) + 1)},
+	}
+	for _, move := range moves {
+		records = append(records, record{"MOVE", move})
+	}
+
+	for _, rec := range records {
+		err := writeRecord(w, rec.key, rec.value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
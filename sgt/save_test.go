@@ -0,0 +1,109 @@
+package sgt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/erikbryant/magnets/common"
+)
+
+func TestReadSave(t *testing.T) {
+	header := "SAVEFILE:41:Simon Tatham's Portable Puzzle Collection\n" +
+		"VERSION :1:1\n" +
+		"GAME    :7:Magnets\n" +
+		"PARAMS  :5:3x3dt\n" +
+		"CPARAMS :5:3x3dt\n" +
+		"DESC    :25:201,102,120,111,LRTT*BBLR\n"
+
+	testCases := []struct {
+		s        string
+		expected []string
+	}{
+		{header + "NSTATES :1:1\nSTATEPOS:1:1\n", []string{"   ", " ⊠ ", "   "}},
+		{header + "NSTATES :1:3\nSTATEPOS:1:3\nMOVE    :4:+0,0\nMOVE    :4:.2,0\n", []string{"+-#", " ⊠#", "   "}},
+		// The player stepped back one move.
+		{header + "NSTATES :1:3\nSTATEPOS:1:2\nMOVE    :4:+0,0\nMOVE    :4:.2,0\n", []string{"+- ", " ⊠ ", "   "}},
+		// Either end of a domino can be given.
+		{header + "NSTATES :1:2\nSTATEPOS:1:2\nMOVE    :4:+1,2\n", []string{"   ", " ⊠ ", " +-"}},
+		// Several actions in one move, notes, clearing and solving.
+		{header + "NSTATES :1:3\nSTATEPOS:1:3\nMOVE    :14:-0,1;?2,2;+1,0\nSOLVE   :11:S; 0,1;.2,1\n", []string{"-+#", " ⊠#", "   "}},
+		// A restart clears the board.
+		{header + "NSTATES :1:4\nSTATEPOS:1:4\nMOVE    :4:+0,0\nRESTART :25:201,102,120,111,LRTT*BBLR\nMOVE    :4:-0,2\n", []string{"   ", "+⊠ ", "-  "}},
+	}
+
+	for _, testCase := range testCases {
+		game, p, err := ReadSave(strings.NewReader(testCase.s))
+		if err != nil {
+			t.Errorf("ERROR: Unable to read %q: %s", testCase.s, err)
+			continue
+		}
+		if p.String() != "3x3dt" {
+			t.Errorf("ERROR: Expected params 3x3dt got %s", p)
+		}
+		for row, line := range testCase.expected {
+			for col, r := range []rune(line) {
+				if r == ' ' {
+					r = common.Empty
+				}
+				if game.Guess.Get(row, col, false) != r {
+					t.Errorf("ERROR: For %q expected %c at %d, %d got %c", testCase.s, r, row, col, game.Guess.Get(row, col, false))
+				}
+			}
+		}
+	}
+}
+
+func TestReadSaveErrors(t *testing.T) {
+	header := "SAVEFILE:41:Simon Tatham's Portable Puzzle Collection\n" +
+		"VERSION :1:1\n" +
+		"GAME    :7:Magnets\n" +
+		"PARAMS  :5:3x3dt\n" +
+		"DESC    :25:201,102,120,111,LRTT*BBLR\n"
+
+	testCases := []string{
+		"",
+		"not a save file",
+		"SAVEFILE:41:Simon Tatham's Portable Puzzle Collection\nGAME    :4:Dominosa\n",
+		"SAVEFILE:41:Simon Tatham's Portable Puzzle Collection\nGAME    :7:Magnets\n",
+		header + "NSTATES :1:2\nSTATEPOS:1:2\n",
+		header + "NSTATES :1:2\nSTATEPOS:1:3\nMOVE    :4:+0,0\n",
+		header + "NSTATES :1:2\nSTATEPOS:1:2\nMOVE    :4:+1,1\n",
+		header + "NSTATES :1:2\nSTATEPOS:1:2\nMOVE    :4:+3,0\n",
+		header + "NSTATES :1:2\nSTATEPOS:1:2\nMOVE    :9:+0,0\n",
+		header + "NSTATES :1:x\n",
+	}
+
+	for _, s := range testCases {
+		_, _, err := ReadSave(strings.NewReader(s))
+		if err == nil {
+			t.Errorf("ERROR: Expected an error reading %q", s)
+		}
+	}
+}
+
+func TestWriteSave(t *testing.T) {
+	s := "SAVEFILE:41:Simon Tatham's Portable Puzzle Collection\n" +
+		"VERSION :1:1\n" +
+		"GAME    :7:Magnets\n" +
+		"PARAMS  :5:3x3dt\n" +
+		"CPARAMS :5:3x3dt\n" +
+		"DESC    :25:201,102,120,111,LRTT*BBLR\n" +
+		"NSTATES :1:3\n" +
+		"STATEPOS:1:3\n" +
+		"MOVE    :4:+0,0\n" +
+		"MOVE    :4:.2,0\n"
+
+	game, p, err := ReadSave(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("ERROR: Unable to read save file: %s", err)
+	}
+
+	var sb strings.Builder
+	err = WriteSave(&sb, game, p)
+	if err != nil {
+		t.Errorf("ERROR: Unable to write save file: %s", err)
+	}
+	if sb.String() != s {
+		t.Errorf("ERROR: Expected\n%s\ngot\n%s", s, sb.String())
+	}
+}
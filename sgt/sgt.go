@@ -0,0 +1,295 @@
+package sgt
+
+// This package reads and writes the identifiers that Simon Tatham's Portable
+// Puzzle Collection uses for Magnets, so that games can move between this
+// tool and the reference desktop and mobile apps.
+//
+// Parameters are the size, optionally followed by 'd' and a difficulty
+// character ('e' for easy, 't' for tricky), and 'S' if clues are stripped:
+//
+//	6x5dt
+//	8x8deS
+//
+// A game ID is either the parameters and a description, or the parameters
+// and a random seed:
+//
+//	6x5dt:323232,33333,232323,33333,TLRLRTBLRTTBLRTBBTLRBLRBLRLRLR
+//	6x5dt#12345
+//
+// The description is the same as our serial form without the size (see
+// serialize.go). A random seed is fed to our own generator, so the game it
+// makes is not the same one the reference apps would make from that seed.
+// Once generated, though, its description can be used in either.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/erikbryant/magnets/magnets"
+	"github.com/erikbryant/magnets/solver"
+)
+
+// Params are the settings for a game.
+type Params struct {
+	Width  int
+	Height int
+	// Difficulty is solver.Easy or solver.Tricky.
+	Difficulty solver.Difficulty
+	// StripClues removes as many clues as it can while leaving the game
+	// solvable at its difficulty.
+	StripClues bool
+}
+
+var (
+	// DefaultParams are the reference apps' default settings.
+	DefaultParams = Params{Width: 6, Height: 5, Difficulty: solver.Tricky}
+
+	// diffChars are the characters for each difficulty.
+	diffChars = map[solver.Difficulty]byte{
+		solver.Easy:   'e',
+		solver.Tricky: 't',
+	}
+
+	// errFound stops the generator once it has a game.
+	errFound = errors.New("found")
+
+	// maxAttempts is how many games Generate tries before it gives up on
+	// finding one of the right difficulty. The constraint-based solver
+	// cannot solve many games, so some params rarely or never come up.
+	maxAttempts = 10000
+)
+
+// ParseParams reads a parameter string. Anything not given keeps its default
+// value. A single number is both the width and the height.
+func ParseParams(s string) (Params, error) {
+	p := DefaultParams
+
+	// number reads the digits at the start of s.
+	number := func() (int, bool) {
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == 0 {
+			return 0, false
+		}
+		n, err := strconv.Atoi(s[:i])
+		s = s[i:]
+		return n, err == nil
+	}
+
+	n, ok := number()
+	if !ok {
+		return p, fmt.Errorf("params %q do not start with a size", s)
+	}
+	p.Width, p.Height = n, n
+	if len(s) > 0 && s[0] == 'x' {
+		s = s[1:]
+		p.Height, ok = number()
+		if !ok {
+			return p, fmt.Errorf("params are missing the height")
+		}
+	}
+
+	for len(s) > 0 {
+		switch s[0] {
+		case 'd':
+			if len(s) < 2 {
+				return p, fmt.Errorf("params are missing the difficulty")
+			}
+			found := false
+			for d, c := range diffChars {
+				if c == s[1] {
+					p.Difficulty = d
+					found = true
+				}
+			}
+			if !found {
+				return p, fmt.Errorf("unknown difficulty '%c'", s[1])
+			}
+			s = s[2:]
+		case 'S':
+			p.StripClues = true
+			s = s[1:]
+		default:
+			return p, fmt.Errorf("unexpected '%c' in params", s[0])
+		}
+	}
+
+	if p.Width < 2 || p.Height < 2 {
+		return p, fmt.Errorf("size %dx%d is too small", p.Width, p.Height)
+	}
+
+	return p, nil
+}
+
+// String returns the full parameter string.
+func (p Params) String() string {
+	s := fmt.Sprintf("%dx%dd%c", p.Width, p.Height, diffChars[p.Difficulty])
+	if p.StripClues {
+		s += "S"
+	}
+	return s
+}
+
+// ID returns the game ID the reference apps read, which is the size and the
+// description.
+func ID(game magnets.Game) (string, error) {
+	serial, ok := game.Serialize()
+	if !ok {
+		return "", fmt.Errorf("could not serialize game")
+	}
+	return serial, nil
+}
+
+// ParseID reads a game ID, either params:description or params#seed. A plain
+// serial is a params:description ID, and our codes (see binary.go) are also
+// read.
+func ParseID(ctx context.Context, id string) (magnets.Game, Params, error) {
+	// A '#' after the ':' is part of the description, as in a serial that
+	// carries its solution.
+	if i := strings.IndexRune(id, '#'); i != -1 && !strings.ContainsRune(id[:i], ':') {
+		p, err := ParseParams(id[:i])
+		if err != nil {
+			return magnets.Game{}, p, err
+		}
+		game, err := Generate(ctx, p, id[i+1:])
+		return game, p, err
+	}
+
+	i := strings.IndexRune(id, ':')
+	if i == -1 {
+		// It may be one of our codes.
+		game, ok := magnets.Deserialize(id)
+		if !ok {
+			return game, DefaultParams, fmt.Errorf("game ID %q has neither ':' nor '#' and is not a code", id)
+		}
+		p := DefaultParams
+		p.Width, p.Height = game.Guess.Width(), game.Guess.Height()
+		return game, p, nil
+	}
+	p, err := ParseParams(id[:i])
+	if err != nil {
+		return magnets.Game{}, p, err
+	}
+
+	serial := fmt.Sprintf("%dx%d%s", p.Width, p.Height, id[i:])
+	game, ok := magnets.Deserialize(serial)
+	if !ok {
+		return game, p, fmt.Errorf("invalid game description %q", id[i+1:])
+	}
+
+	return game, p, nil
+}
+
+// seedValue turns a random seed, which may be any string, into a number.
+func seedValue(seed string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(seed))
+	return int64(h.Sum64())
+}
+
+// Generate creates a game with the given params from a random seed. The same
+// params and seed always make the same game.
+func Generate(ctx context.Context, p Params, seed string) (magnets.Game, error) {
+	if _, ok := diffChars[p.Difficulty]; !ok {
+		return magnets.Game{}, fmt.Errorf("unknown difficulty %s", p.Difficulty)
+	}
+
+	opts := magnets.GenerateOptions{
+		Width:   p.Width,
+		Height:  p.Height,
+		Workers: 1,
+		Seed:    seedValue(seed),
+	}
+
+	// Like the reference apps, keep going until the game is exactly as hard
+	// as asked for: solvable at that difficulty, but not below it.
+	var game magnets.Game
+	attempts := 0
+	err := magnets.Generate(ctx, opts, func(g magnets.Game) error {
+		attempts++
+		if attempts > maxAttempts {
+			return fmt.Errorf("no %s game found in %d attempts", p, maxAttempts)
+		}
+		grade, err := solver.Grade(ctx, g)
+		if err != nil {
+			return err
+		}
+		if grade != p.Difficulty {
+			return nil
+		}
+		game = g
+		return errFound
+	})
+	if err != errFound {
+		return game, err
+	}
+
+	if p.StripClues {
+		return stripClues(ctx, game, p.Difficulty, rand.New(rand.NewSource(opts.Seed)))
+	}
+
+	return game, nil
+}
+
+// stripClues removes clues, in random order, as long as the game stays
+// solvable at the given difficulty.
+func stripClues(ctx context.Context, game magnets.Game, difficulty solver.Difficulty, rng *rand.Rand) (magnets.Game, error) {
+	serial, err := ID(game)
+	if err != nil {
+		return game, err
+	}
+	solution, _ := game.SerializeWithSolution()
+	answer := solution[len(serial):]
+
+	// The clues are the first four fields after the size. A clue is one
+	// character, or a count in brackets. Split the serial into tokens so
+	// that blanking a bracketed clue does not move the others.
+	i := strings.IndexRune(serial, ':') + 1
+	tokens := []string{serial[:i]}
+	var clues []int
+	for fields := 0; fields < 4; i++ {
+		switch serial[i] {
+		case ',':
+			fields++
+			tokens = append(tokens, ",")
+			continue
+		case '[':
+			end := i + strings.IndexRune(serial[i:], ']')
+			tokens = append(tokens, serial[i:end+1])
+			i = end
+		default:
+			tokens = append(tokens, serial[i:i+1])
+		}
+		clues = append(clues, len(tokens)-1)
+	}
+	tokens = append(tokens, serial[i:])
+	rng.Shuffle(len(clues), func(i, j int) { clues[i], clues[j] = clues[j], clues[i] })
+
+	for _, i := range clues {
+		clue := tokens[i]
+		tokens[i] = "."
+		candidate := strings.Join(tokens, "")
+		g, ok := magnets.Deserialize(candidate + answer)
+		if !ok {
+			return game, fmt.Errorf("could not deserialize %s", candidate)
+		}
+		grade, err := solver.Grade(ctx, g)
+		if err != nil {
+			return game, err
+		}
+		if grade <= difficulty {
+			game = g
+		} else {
+			tokens[i] = clue
+		}
+	}
+
+	return game, nil
+}
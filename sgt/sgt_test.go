@@ -0,0 +1,136 @@
+package sgt
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/erikbryant/magnets/solver"
+)
+
+func TestParseParams(t *testing.T) {
+	testCases := []struct {
+		s        string
+		expected Params
+		full     string
+	}{
+		{"6x5", Params{Width: 6, Height: 5, Difficulty: solver.Tricky}, "6x5dt"},
+		{"6x5dt", Params{Width: 6, Height: 5, Difficulty: solver.Tricky}, "6x5dt"},
+		{"8x8deS", Params{Width: 8, Height: 8, Difficulty: solver.Easy, StripClues: true}, "8x8deS"},
+		{"7", Params{Width: 7, Height: 7, Difficulty: solver.Tricky}, "7x7dt"},
+		{"10x12S", Params{Width: 10, Height: 12, Difficulty: solver.Tricky, StripClues: true}, "10x12dtS"},
+	}
+
+	for _, testCase := range testCases {
+		p, err := ParseParams(testCase.s)
+		if err != nil {
+			t.Errorf("ERROR: Unable to parse %s: %s", testCase.s, err)
+			continue
+		}
+		if p != testCase.expected {
+			t.Errorf("ERROR: For %s expected %+v got %+v", testCase.s, testCase.expected, p)
+		}
+		if p.String() != testCase.full {
+			t.Errorf("ERROR: For %s expected %s got %s", testCase.s, testCase.full, p.String())
+		}
+	}
+
+	for _, s := range []string{"", "x5", "6x", "6x5dz", "6x5d", "6x5q", "1x1"} {
+		_, err := ParseParams(s)
+		if err == nil {
+			t.Errorf("ERROR: Expected an error parsing %q", s)
+		}
+	}
+}
+
+func TestParseID(t *testing.T) {
+	testCases := []struct {
+		id       string
+		expected string
+	}{
+		// The examples from serialize.go.
+		{"3x3:201,102,120,111,LRTT*BBLR", "3x3:201,102,120,111,LRTT*BBLR"},
+		{"5x5:.2..1,3..1.,.2..2,2..2.,LRLRTTLRTBBT*BTTBLRBBLRLR", "5x5:.2..1,3..1.,.2..2,2..2.,LRLRTTLRTBBT*BTTBLRBBLRLR"},
+		// Full params.
+		{"6x6dt:322223,323132,232223,232223,LRTLRTTTBLRBBBTTLRLRBBLRTTLRTTBBLRBB", "6x6:322223,323132,232223,232223,LRTLRTTTBLRBBBTTLRLRBBLRTTLRTTBBLRBB"},
+		{"6dtS:322223,323132,232223,232223,LRTLRTTTBLRBBBTTLRLRBBLRTTLRTTBBLRBB", "6x6:322223,323132,232223,232223,LRTLRTTTBLRBBBTTLRLRBBLRTTLRTTBBLRBB"},
+		// Our code for the 3x3 example.
+		{"AQMDACARAhIBEWhA", "3x3:201,102,120,111,LRTT*BBLR"},
+		// A serial with its solution, which has a '#' that is not a seed.
+		{"3x3:201,102,120,111,LRTT*BBLR,+#--", "3x3:201,102,120,111,LRTT*BBLR"},
+	}
+
+	for _, testCase := range testCases {
+		game, _, err := ParseID(context.Background(), testCase.id)
+		if err != nil {
+			t.Errorf("ERROR: Unable to parse %s: %s", testCase.id, err)
+			continue
+		}
+		answer, err := ID(game)
+		if err != nil {
+			t.Errorf("ERROR: Unable to write the ID for %s: %s", testCase.id, err)
+		}
+		if answer != testCase.expected {
+			t.Errorf("ERROR: For %s expected %s got %s", testCase.id, testCase.expected, answer)
+		}
+	}
+
+	// The solution is kept.
+	solution := "3x3:201,102,120,111,LRTT*BBLR,+#--"
+	game, _, err := ParseID(context.Background(), solution)
+	if err != nil {
+		t.Fatalf("ERROR: Unable to parse %s: %s", solution, err)
+	}
+	answer, _ := game.SerializeWithSolution()
+	if answer != solution {
+		t.Errorf("ERROR: For %s got %s", solution, answer)
+	}
+
+	for _, id := range []string{"", "3x3", "3x3q:201,102,120,111,LRTT*BBLR", "3x3:201,102,120,111,LRTT"} {
+		_, _, err := ParseID(context.Background(), id)
+		if err == nil {
+			t.Errorf("ERROR: Expected an error parsing %q", id)
+		}
+	}
+}
+
+func TestParseIDSeed(t *testing.T) {
+	testCases := []string{
+		"5x3dt#1",
+		"5x3dtS#1",
+		"3x3dt#hello",
+	}
+
+	for _, id := range testCases {
+		game, p, err := ParseID(context.Background(), id)
+		if err != nil {
+			t.Errorf("ERROR: Unable to parse %s: %s", id, err)
+			continue
+		}
+		first, _ := ID(game)
+
+		// The same seed always makes the same game.
+		game, _, _ = ParseID(context.Background(), id)
+		second, _ := ID(game)
+		if first != second {
+			t.Errorf("ERROR: For %s got %s and then %s", id, first, second)
+		}
+
+		grade, err := solver.Grade(context.Background(), game)
+		if err != nil || grade != p.Difficulty {
+			t.Errorf("ERROR: For %s expected %s got %s", id, p.Difficulty, grade)
+		}
+
+		if p.StripClues != strings.Contains(first, ".") {
+			t.Errorf("ERROR: For %s unexpected clues in %s", id, first)
+		}
+	}
+
+	// Some params are out of reach.
+	maxAttempts = 20
+	defer func() { maxAttempts = 10000 }()
+	_, _, err := ParseID(context.Background(), "4x4de#1")
+	if err == nil {
+		t.Errorf("ERROR: Expected an error generating 4x4de")
+	}
+}
@@ -25,21 +25,34 @@ import (
 	"github.com/erikbryant/magnets/magnets"
 )
 
-// CBS is the constraint-based solver representation.
-type CBS [][]map[rune]bool
+// CBS is the constraint-based solver representation. Each solve has its own,
+// so several can run at once.
+type CBS struct {
+	// cells holds the possibilities for each cell.
+	cells [][]map[rune]bool
+	// dirty is set when a rule makes progress.
+	dirty bool
+}
 
-var (
-	dirty = false
-)
+// contradictionError is what the CBS panics with when the game, as far as it
+// has been solved, breaks the rules. Any other panic is a bug.
+type contradictionError struct {
+	msg string
+}
+
+// Error returns the description of the contradiction.
+func (e contradictionError) Error() string {
+	return e.msg
+}
 
 // new takes a game and returns a new, initialized constraint-based solver object for that game.
-func new(game magnets.Game) CBS {
-	cbs := make(CBS, game.Guess.Height())
+func new(game magnets.Game) *CBS {
+	cbs := &CBS{cells: make([][]map[rune]bool, game.Guess.Height())}
 
 	for row := 0; row < game.Guess.Height(); row++ {
-		cbs[row] = make([]map[rune]bool, game.Guess.Width())
+		cbs.cells[row] = make([]map[rune]bool, game.Guess.Width())
 		for col := 0; col < game.Guess.Width(); col++ {
-			cbs[row][col] = make(map[rune]bool)
+			cbs.cells[row][col] = make(map[rune]bool)
 		}
 	}
 
@@ -47,7 +60,7 @@ func new(game magnets.Game) CBS {
 		row, col := cell.Unpack()
 		r := game.Guess.Get(row, col, false)
 		if r == common.Wall {
-			cbs[row][col] = map[rune]bool{r: true}
+			cbs.cells[row][col] = map[rune]bool{r: true}
 			continue
 		}
 		if r != common.Empty {
@@ -56,9 +69,9 @@ func new(game magnets.Game) CBS {
 		}
 
 		// Each cell is a set of possibilities. At the start, each case is possible.
-		cbs[row][col][common.Positive] = true
-		cbs[row][col][common.Negative] = true
-		cbs[row][col][common.Neutral] = true
+		cbs.cells[row][col][common.Positive] = true
+		cbs.cells[row][col][common.Negative] = true
+		cbs.cells[row][col][common.Neutral] = true
 	}
 
 	return cbs
@@ -66,13 +79,13 @@ func new(game magnets.Game) CBS {
 
 // getOnlyPossibility returns the only remaining value in the CBS, or if there
 // is not only one possibility, it panics.
-func (cbs CBS) getOnlyPossibility(row, col int) (r rune) {
-	if len(cbs[row][col]) != 1 {
-		msg := fmt.Sprintf("There is not only one possibility %v", cbs[row][col])
+func (cbs *CBS) getOnlyPossibility(row, col int) (r rune) {
+	if len(cbs.cells[row][col]) != 1 {
+		msg := fmt.Sprintf("There is not only one possibility %v", cbs.cells[row][col])
 		panic(msg)
 	}
 
-	for r = range cbs[row][col] {
+	for r = range cbs.cells[row][col] {
 	}
 
 	return
@@ -81,31 +94,31 @@ func (cbs CBS) getOnlyPossibility(row, col int) (r rune) {
 // setFrame takes a coordinate and a polarity, sets that, and sets the other end
 // of the frame to correspond. This is different from the other implementations
 // in that it also keeps track of whether the board is dirty and updates the CBS.
-func (cbs CBS) setFrame(game magnets.Game, row, col int, r rune) {
+func (cbs *CBS) setFrame(game magnets.Game, row, col int, r rune) {
 	rowEnd, colEnd := game.GetFrameEnd(row, col)
 
 	if r != game.Guess.Get(row, col, false) || common.Negate(r) != game.Guess.Get(rowEnd, colEnd, false) {
-		dirty = true
+		cbs.dirty = true
 	}
 
 	// Set this end of the frame.
 	game.Guess.Set(row, col, r, false)
-	cbs[row][col] = map[rune]bool{r: true}
+	cbs.cells[row][col] = map[rune]bool{r: true}
 
 	// Set the other end of the frame.
 	game.Guess.Set(rowEnd, colEnd, common.Negate(r), false)
-	cbs[rowEnd][colEnd] = map[rune]bool{common.Negate(r): true}
+	cbs.cells[rowEnd][colEnd] = map[rune]bool{common.Negate(r): true}
 }
 
 // decided returns true if the final value of the cell has been decided,
 // false otherwise.
-func (cbs CBS) decided(row, col int) bool {
-	return len(cbs[row][col]) == 1
+func (cbs *CBS) decided(row, col int) bool {
+	return len(cbs.cells[row][col]) == 1
 }
 
 // possibility returns true if the given rune is still a possibility, false otherwise.
-func (cbs CBS) possibility(row, col int, r rune) bool {
-	if val, ok := cbs[row][col][r]; ok {
+func (cbs *CBS) possibility(row, col int, r rune) bool {
+	if val, ok := cbs.cells[row][col][r]; ok {
 		return val
 	}
 
@@ -113,22 +126,22 @@ func (cbs CBS) possibility(row, col int, r rune) bool {
 }
 
 // unsetHelper does the actual work of removing the given possibility from the cbs.
-func (cbs CBS) unsetHelper(row, col int, r rune) {
+func (cbs *CBS) unsetHelper(row, col int, r rune) {
 	if cbs.possibility(row, col, r) {
-		dirty = true
+		cbs.dirty = true
 	}
 
-	delete(cbs[row][col], r)
+	delete(cbs.cells[row][col], r)
 
-	if len(cbs[row][col]) == 0 {
+	if len(cbs.cells[row][col]) == 0 {
 		msg := fmt.Sprintf("All possibilities have been deleted from cbs %d, %d", row, col)
-		panic(msg)
+		panic(contradictionError{msg})
 	}
 }
 
 // unsetPossibility removes the given rune from the CBS' list of potential
 // cell values.
-func (cbs CBS) unsetPossibility(game magnets.Game, row, col int, r rune) {
+func (cbs *CBS) unsetPossibility(game magnets.Game, row, col int, r rune) {
 	cbs.unsetHelper(row, col, r)
 	rowEnd, colEnd := game.GetFrameEnd(row, col)
 	if rowEnd == -1 || colEnd == -1 {
@@ -139,10 +152,10 @@ func (cbs CBS) unsetPossibility(game magnets.Game, row, col int, r rune) {
 
 // unsetPossibilityRow removes the given rune from the CBS' list of potential
 // cell values for an entire row.
-func (cbs CBS) unsetPossibilityRow(game magnets.Game, row int, r rune) {
+func (cbs *CBS) unsetPossibilityRow(game magnets.Game, row int, r rune) {
 	for col := 0; col < game.Guess.Width(); col++ {
 		// Never remove the last possibility.
-		if len(cbs[row][col]) > 1 {
+		if len(cbs.cells[row][col]) > 1 {
 			cbs.unsetPossibility(game, row, col, r)
 		}
 	}
@@ -150,10 +163,10 @@ func (cbs CBS) unsetPossibilityRow(game magnets.Game, row int, r rune) {
 
 // unsetPossibilityCol removes the given rune from the CBS' list of potential
 // cell values for an entire col.
-func (cbs CBS) unsetPossibilityCol(game magnets.Game, col int, r rune) {
+func (cbs *CBS) unsetPossibilityCol(game magnets.Game, col int, r rune) {
 	for row := 0; row < game.Guess.Height(); row++ {
 		// Never remove the last possibility.
-		if len(cbs[row][col]) > 1 {
+		if len(cbs.cells[row][col]) > 1 {
 			cbs.unsetPossibility(game, row, col, r)
 		}
 	}
@@ -179,7 +192,7 @@ func colNeeds(game magnets.Game, col int, r rune) int {
 // given polarity. This includes cells that have already been solved.
 // Note that each horizontal frame in this row only adds one possibility
 // since both ends of the magnet cannot be the same polarity.
-func (cbs CBS) rowHasSpaceForTotal(game magnets.Game, row int, r rune) int {
+func (cbs *CBS) rowHasSpaceForTotal(game magnets.Game, row int, r rune) int {
 	count := 0
 
 	for col := 0; col < game.Guess.Width(); col++ {
@@ -190,16 +203,16 @@ func (cbs CBS) rowHasSpaceForTotal(game magnets.Game, row int, r rune) int {
 			case common.Right:
 				continue
 			case common.Left:
-				if cbs[row][col][r] || cbs[row][col+1][r] {
+				if cbs.cells[row][col][r] || cbs.cells[row][col+1][r] {
 					count++
 				}
 			default:
-				if cbs[row][col][r] {
+				if cbs.cells[row][col][r] {
 					count++
 				}
 			}
 		} else {
-			if cbs[row][col][r] {
+			if cbs.cells[row][col][r] {
 				count++
 			}
 		}
@@ -212,7 +225,7 @@ func (cbs CBS) rowHasSpaceForTotal(game magnets.Game, row int, r rune) int {
 // given polarity. This includes cells that have already been solved.
 // Note that each vertical frame in this row only adds one possibility
 // since both ends of the magnet cannot be the same polarity.
-func (cbs CBS) colHasSpaceForTotal(game magnets.Game, col int, r rune) int {
+func (cbs *CBS) colHasSpaceForTotal(game magnets.Game, col int, r rune) int {
 	count := 0
 
 	for row := 0; row < game.Guess.Height(); row++ {
@@ -223,16 +236,16 @@ func (cbs CBS) colHasSpaceForTotal(game magnets.Game, col int, r rune) int {
 			case common.Down:
 				continue
 			case common.Up:
-				if cbs[row][col][r] || cbs[row+1][col][r] {
+				if cbs.cells[row][col][r] || cbs.cells[row+1][col][r] {
 					count++
 				}
 			default:
-				if cbs[row][col][r] {
+				if cbs.cells[row][col][r] {
 					count++
 				}
 			}
 		} else {
-			if cbs[row][col][r] {
+			if cbs.cells[row][col][r] {
 				count++
 			}
 		}
@@ -243,10 +256,10 @@ func (cbs CBS) colHasSpaceForTotal(game magnets.Game, col int, r rune) int {
 
 // rowHasSpaceForRemaining counts how many *possible* locations are present for
 // the given polarity. This DOES NOT INCLUDE cells that have already been solved.
-func (cbs CBS) rowHasSpaceForRemaining(game magnets.Game, row int, r rune) int {
+func (cbs *CBS) rowHasSpaceForRemaining(game magnets.Game, row int, r rune) int {
 	count := 0
 	for col := 0; col < game.Guess.Width(); col++ {
-		if game.Guess.Get(row, col, false) == common.Empty && cbs[row][col][r] {
+		if game.Guess.Get(row, col, false) == common.Empty && cbs.cells[row][col][r] {
 			count++
 		}
 	}
@@ -255,10 +268,10 @@ func (cbs CBS) rowHasSpaceForRemaining(game magnets.Game, row int, r rune) int {
 
 // colHasSpaceForRemaining counts how many *possible* locations are present for
 // the given polarity. This DOES NOT INCLUDE cells that have already been solved.
-func (cbs CBS) colHasSpaceForRemaining(game magnets.Game, col int, r rune) int {
+func (cbs *CBS) colHasSpaceForRemaining(game magnets.Game, col int, r rune) int {
 	count := 0
 	for row := 0; row < game.Guess.Height(); row++ {
-		if game.Guess.Get(row, col, false) == common.Empty && cbs[row][col][r] {
+		if game.Guess.Get(row, col, false) == common.Empty && cbs.cells[row][col][r] {
 			count++
 		}
 	}
@@ -266,7 +279,7 @@ func (cbs CBS) colHasSpaceForRemaining(game magnets.Game, col int, r rune) int {
 }
 
 // validate returns an error if the game or the CBS is inconsistent.
-func (cbs CBS) validate(game magnets.Game) error {
+func (cbs *CBS) validate(game magnets.Game) error {
 	if !game.Valid() {
 		return fmt.Errorf("invalid game board state detected")
 	}
@@ -287,20 +300,20 @@ func (cbs CBS) validate(game magnets.Game) error {
 		row, col := cell.Unpack()
 		r := game.Guess.Get(row, col, false)
 		// This is already solved, so the CBS should only have r in it.
-		for key := range cbs[row][col] {
+		for key := range cbs.cells[row][col] {
 			if key != r {
 				return fmt.Errorf("ERROR: CBS %d, %d had extraneous '%c'", row, col, key)
 			}
 		}
-		if len(cbs[row][col]) != 1 {
-			return fmt.Errorf("ERROR: CBS %d, %d has wrong length. Expected 1 got %d", row, col, len(cbs[row][col]))
+		if len(cbs.cells[row][col]) != 1 {
+			return fmt.Errorf("ERROR: CBS %d, %d has wrong length. Expected 1 got %d", row, col, len(cbs.cells[row][col]))
 		}
 	}
 
 	// Validate that the CBS contains only expected possibilities.
-	for row := range cbs {
-		for col := range cbs[row] {
-			for key := range cbs[row][col] {
+	for row := range cbs.cells {
+		for col := range cbs.cells[row] {
+			for key := range cbs.cells[row][col] {
 				switch key {
 				case common.Positive:
 					continue
@@ -321,31 +334,31 @@ func (cbs CBS) validate(game magnets.Game) error {
 }
 
 // print prints a formatted representation of the cbs.
-func (cbs CBS) print() {
-	fmt.Printf("CBS (%dx%d)\n", len(cbs[0]), len(cbs))
+func (cbs *CBS) print() {
+	fmt.Printf("CBS (%dx%d)\n", len(cbs.cells[0]), len(cbs.cells))
 
 	fmt.Printf("   + ")
-	for i := 0; i < len(cbs[0]); i++ {
+	for i := 0; i < len(cbs.cells[0]); i++ {
 		fmt.Printf("―")
 	}
 	fmt.Printf("\n")
 
-	for row := range cbs {
+	for row := range cbs.cells {
 		fmt.Printf("   | ")
-		for col := range cbs[row] {
-			if len(cbs[row][col]) == 1 {
-				for r := range cbs[row][col] {
+		for col := range cbs.cells[row] {
+			if len(cbs.cells[row][col]) == 1 {
+				for r := range cbs.cells[row][col] {
 					fmt.Printf("%c", r)
 				}
 			} else {
-				fmt.Printf("%d", len(cbs[row][col]))
+				fmt.Printf("%d", len(cbs.cells[row][col]))
 			}
 		}
 		fmt.Println(" |")
 	}
 
 	fmt.Printf("     ")
-	for i := 0; i < len(cbs[0]); i++ {
+	for i := 0; i < len(cbs.cells[0]); i++ {
 		fmt.Printf("―")
 	}
 	fmt.Printf(" -\n")
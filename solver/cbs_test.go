@@ -15,15 +15,15 @@ func TestNewNoWall(t *testing.T) {
 
 	cbs := new(game)
 
-	answer := len(cbs)
+	answer := len(cbs.cells)
 	if answer != 4 {
 		t.Errorf("ERROR: Expected 4, got %d", answer)
 	}
-	answer = len(cbs[0])
+	answer = len(cbs.cells[0])
 	if answer != 5 {
 		t.Errorf("ERROR: Expected 5, got %d", answer)
 	}
-	answer = len(cbs[0][0])
+	answer = len(cbs.cells[0][0])
 	if answer != 3 {
 		t.Errorf("ERROR: Expected 3, got %d", answer)
 	}
@@ -42,15 +42,15 @@ func TestNewWithWall(t *testing.T) {
 
 	cbs := new(game)
 
-	answer := len(cbs)
+	answer := len(cbs.cells)
 	if answer != 7 {
 		t.Errorf("ERROR: Expected 7, got %d", answer)
 	}
-	answer = len(cbs[0])
+	answer = len(cbs.cells[0])
 	if answer != 5 {
 		t.Errorf("ERROR: Expected 5, got %d", answer)
 	}
-	answer = len(cbs[0][0])
+	answer = len(cbs.cells[0][0])
 	if answer != 3 {
 		t.Errorf("ERROR: Expected 3, got %d", answer)
 	}
@@ -78,14 +78,14 @@ func TestSetFrame(t *testing.T) {
 	cbs := new(game)
 
 	for _, testCase := range testCases {
-		dirty = false
+		cbs.dirty = false
 		cbs.setFrame(game, 0, 0, testCase.r)
 		answer := game.Guess.Get(0, 1, false)
 		if answer != testCase.expected {
 			t.Errorf("ERROR: Expected '%c' got '%c'", testCase.expected, answer)
 		}
-		if !dirty {
-			t.Errorf("ERROR: Expected dirty = true, got dirty = %v", dirty)
+		if !cbs.dirty {
+			t.Errorf("ERROR: Expected dirty = true, got dirty = %v", cbs.dirty)
 		}
 	}
 }
@@ -98,39 +98,39 @@ func TestUnsetPossibility(t *testing.T) {
 
 	cbs := new(game)
 
-	answer := len(cbs[0][0])
+	answer := len(cbs.cells[0][0])
 	if answer != 3 {
 		t.Errorf("ERROR: Expected 3, got %d", answer)
 	}
 
-	dirty = false
+	cbs.dirty = false
 	cbs.unsetPossibility(game, 0, 0, common.Positive)
-	answer = len(cbs[0][0])
+	answer = len(cbs.cells[0][0])
 	if answer != 2 {
 		t.Errorf("ERROR: Expected 2, got %d", answer)
 	}
-	if !dirty {
-		t.Errorf("ERROR: Expected dirty = true, got dirty = %v", dirty)
+	if !cbs.dirty {
+		t.Errorf("ERROR: Expected dirty = true, got dirty = %v", cbs.dirty)
 	}
 
-	dirty = false
+	cbs.dirty = false
 	cbs.unsetPossibility(game, 0, 0, common.Positive)
-	answer = len(cbs[0][0])
+	answer = len(cbs.cells[0][0])
 	if answer != 2 {
 		t.Errorf("ERROR: Expected still to be 2, got %d", answer)
 	}
-	if dirty {
-		t.Errorf("ERROR: Expected dirty = false, got dirty = %v", dirty)
+	if cbs.dirty {
+		t.Errorf("ERROR: Expected dirty = false, got dirty = %v", cbs.dirty)
 	}
 
-	dirty = false
+	cbs.dirty = false
 	cbs.unsetPossibility(game, 0, 0, common.Negative)
-	answer = len(cbs[0][0])
+	answer = len(cbs.cells[0][0])
 	if answer != 1 {
 		t.Errorf("ERROR: Expected 1, got %d", answer)
 	}
-	if !dirty {
-		t.Errorf("ERROR: Expected dirty = true, got dirty = %v", dirty)
+	if !cbs.dirty {
+		t.Errorf("ERROR: Expected dirty = true, got dirty = %v", cbs.dirty)
 	}
 }
 
@@ -442,7 +442,7 @@ func TestValidate(t *testing.T) {
 		t.Error("validate was supposed to find an error but did not")
 	}
 
-	cbs[1][1] = map[rune]bool{'%': true}
+	cbs.cells[1][1] = map[rune]bool{'%': true}
 	err = cbs.validate(game)
 	if err == nil {
 		t.Error("validate was supposed to find an error but did not")
@@ -0,0 +1,239 @@
+package solver
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/erikbryant/magnets/board"
+	"github.com/erikbryant/magnets/magnets"
+)
+
+// Differential testing. Every registered solver is run on the same games. The
+// games all have exactly one solution, so no solver may report a
+// contradiction, and every solver that finishes must find the same solution.
+// When they disagree the game is minimized and reported. Run the tests with
+// -regressions to also append it to regressionFile, which is read back as part
+// of the corpus.
+
+const (
+	regressionFile = "testcases_regression.txt"
+	generatedSeed  = 1
+)
+
+var regressions = flag.Bool("regressions", false, "append the games the solvers disagree on to "+regressionFile)
+
+// disagreement runs every solver on the game and describes how they disagree.
+// It returns "" if they agree.
+func disagreement(game magnets.Game) string {
+	var problems []string
+	var solvedBy string
+	var solution magnets.Game
+
+	for _, s := range All() {
+		result, _ := s.Solve(context.Background(), game)
+
+		switch result.Status {
+		case Contradiction:
+			problems = append(problems, fmt.Sprintf("%s reports a contradiction", s.Name()))
+		case Solved:
+			check := game
+			check.Guess = result.Solution
+			if !check.Solved() {
+				problems = append(problems, fmt.Sprintf("%s reports a wrong solution", s.Name()))
+				continue
+			}
+			if solvedBy == "" {
+				solvedBy = s.Name()
+				solution = check
+				continue
+			}
+			if !solution.Guess.Equal(result.Solution) {
+				problems = append(problems, fmt.Sprintf("%s and %s found different solutions", solvedBy, s.Name()))
+			}
+		}
+	}
+
+	return strings.Join(problems, "; ")
+}
+
+// minimize removes as many clues from the game as it can while keeping the
+// solution unique and the solvers disagreeing in the same way.
+func minimize(game magnets.Game, problem string) magnets.Game {
+	for i, clue := range game.Clues() {
+		if clue < 0 {
+			continue
+		}
+
+		candidate := game.Clone()
+		candidate.HideClue(i)
+		if candidate.CountSolutionsDLX() != 1 {
+			continue
+		}
+		if disagreement(candidate) == problem {
+			game = candidate
+		}
+	}
+
+	return game
+}
+
+// record minimizes a game the solvers disagree on and reports it. With
+// -regressions it also appends it to the regression file.
+func record(t *testing.T, game magnets.Game, problem string) {
+	serial, _ := game.Serialize()
+	m := minimize(game, problem)
+	minimal, _ := m.Serialize()
+	t.Errorf("ERROR: For %s %s (minimized to %s)", serial, problem, minimal)
+
+	if !*regressions {
+		return
+	}
+
+	f, err := os.OpenFile(regressionFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Errorf("Unable to open %s %s", regressionFile, err)
+		return
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "// %s\n%s\n", problem, minimal)
+	if err != nil {
+		t.Errorf("Unable to write %s %s", regressionFile, err)
+	}
+}
+
+// differential runs the solvers against each game in a testcases file.
+func differential(t *testing.T, file string) {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) && file == regressionFile {
+			return
+		}
+		t.Fatalf("Unable to open testcases %s %s", file, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 0; scanner.Scan(); line++ {
+		testCase := strings.TrimSpace(scanner.Text())
+		if len(testCase) == 0 || strings.HasPrefix(testCase, "//") {
+			continue
+		}
+
+		// In short mode only sample the corpus.
+		if testing.Short() && line%100 != 0 {
+			continue
+		}
+
+		game, ok := magnets.Deserialize(testCase)
+		if !ok {
+			t.Errorf("ERROR: Unable to deserialize %s", testCase)
+			continue
+		}
+
+		if problem := disagreement(game); problem != "" {
+			record(t, game, problem)
+		}
+	}
+}
+
+func TestDifferentialCorpus(t *testing.T) {
+	differential(t, "testcases_solve.txt")
+	differential(t, regressionFile)
+}
+
+func TestDifferentialGenerated(t *testing.T) {
+	games := 200
+	if testing.Short() {
+		games = 20
+	}
+
+	// The games are seeded, so a disagreement can be reproduced from the
+	// options that are logged with it.
+	rng := rand.New(rand.NewSource(generatedSeed))
+	for i := 0; i < games; i++ {
+		opts := magnets.GenerateOptions{
+			Width:   rng.Intn(7) + 2,
+			Height:  rng.Intn(7) + 2,
+			Workers: 1,
+			Seed:    generatedSeed + int64(i),
+			Count:   1,
+		}
+		err := magnets.Generate(context.Background(), opts, func(game magnets.Game) error {
+			if problem := disagreement(game); problem != "" {
+				t.Logf("Generated with %+v", opts)
+				record(t, game, problem)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ERROR: Unable to generate with %+v: %s", opts, err)
+		}
+	}
+}
+
+func TestMinimize(t *testing.T) {
+	// The solvers agree on this game, so every clue that is not needed to
+	// keep the solution unique is removed.
+	serial := "3x4:212,1202,122,2111,TTTBBBLRTLRB"
+	game, ok := magnets.Deserialize(serial)
+	if !ok {
+		t.Fatalf("ERROR: Unable to deserialize %s", serial)
+	}
+
+	answer := minimize(game, "")
+	if slices.Equal(answer.Clues(), game.Clues()) {
+		t.Errorf("ERROR: Expected clues to be removed from %s, got %v", serial, answer.Clues())
+	}
+	if answer.CountSolutionsDLX() != 1 {
+		t.Errorf("ERROR: Expected %v to have one solution", answer.Clues())
+	}
+}
+
+func TestSymmetryInvariance(t *testing.T) {
+	cbs, _ := Lookup("cbs")
+
+	f, err := os.Open("testcases_solve.txt")
+	if err != nil {
+		t.Fatalf("ERROR: Unable to open testcases: %s", err)
+	}
+	defer f.Close()
+
+	// Rotating or mirroring a game must not change whether the solver can
+	// solve it, and the solution must move with the game.
+	games := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() && games < 50 {
+		testCase := strings.TrimSpace(scanner.Text())
+		if testCase == "" || strings.HasPrefix(testCase, "//") {
+			continue
+		}
+		games++
+
+		game, ok := magnets.Deserialize(testCase)
+		if !ok {
+			t.Errorf("ERROR: Unable to deserialize %s", testCase)
+			continue
+		}
+		expected, _ := cbs.Solve(context.Background(), game)
+
+		for _, tr := range board.Transforms {
+			g := game.Transform(tr)
+			result, _ := cbs.Solve(context.Background(), g)
+			if result.Status != expected.Status {
+				t.Errorf("ERROR: For %s %s expected %s got %s", testCase, tr, expected.Status, result.Status)
+				continue
+			}
+			if result.Status == Solved && !result.Solution.Equal(expected.Solution.Transform(tr)) {
+				t.Errorf("ERROR: For %s %s the solution did not move with the game", testCase, tr)
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/erikbryant/magnets/magnets"
+)
+
+// Difficulty is how hard a game is for a person to solve.
+type Difficulty int
+
+const (
+	// Easy games need only the simple counting and neighbor rules.
+	Easy Difficulty = iota
+	// Tricky games need at least one rule that looks at a whole row or col.
+	Tricky
+	// Hard games need the solver to try a guess and find that it fails.
+	Hard
+	// Unsolved games are beyond what the constraint-based solver can do.
+	Unsolved
+)
+
+// String returns the name of the difficulty.
+func (d Difficulty) String() string {
+	switch d {
+	case Easy:
+		return "easy"
+	case Tricky:
+		return "tricky"
+	case Hard:
+		return "hard"
+	case Unsolved:
+		return "unsolved"
+	}
+	return fmt.Sprintf("Difficulty(%d)", int(d))
+}
+
+var (
+	// ruleDifficulty is how hard each of the CBS rules is for a person to
+	// spot. Rules that are not listed are Easy.
+	ruleDifficulty = map[string]Difficulty{
+		"oddRowAllMagnets": Tricky,
+		"oddColAllMagnets": Tricky,
+		"parity":           Tricky,
+		"rowDifference":    Tricky,
+		"colDifference":    Tricky,
+		"probe":            Hard,
+	}
+)
+
+// Grade solves the game with the constraint-based solver and rates it by the
+// hardest rule the solver needed. It does not modify the game.
+func Grade(ctx context.Context, game magnets.Game) (Difficulty, error) {
+	result, err := cbsSolver{}.Solve(ctx, game)
+	if err != nil {
+		return Unsolved, err
+	}
+	if result.Status != Solved {
+		return Unsolved, nil
+	}
+
+	difficulty := Easy
+	for _, rule := range result.Stats.Trace {
+		if ruleDifficulty[rule] > difficulty {
+			difficulty = ruleDifficulty[rule]
+		}
+	}
+
+	return difficulty, nil
+}
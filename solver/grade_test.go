@@ -0,0 +1,36 @@
+package solver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/erikbryant/magnets/magnets"
+)
+
+func TestGrade(t *testing.T) {
+	testCases := []struct {
+		game     string
+		expected Difficulty
+	}{
+		{"3x3:000,000,000,000,LRTTTBBB*", Easy},
+		{"5x3:21211,322,12121,232,TTTTTBBBBBLRLR*", Tricky},
+		{"6x2:111110,32,111101,32,TTTTLRBBBBLR", Hard},
+		// Two solutions.
+		{"2x2:11,11,11,11,TTBB", Unsolved},
+	}
+
+	for _, testCase := range testCases {
+		game, ok := magnets.Deserialize(testCase.game)
+		if !ok {
+			t.Fatalf("Unable to deserialize board %s", testCase.game)
+		}
+
+		answer, err := Grade(context.Background(), game)
+		if err != nil {
+			t.Errorf("ERROR: Unexpected error %v", err)
+		}
+		if answer != testCase.expected {
+			t.Errorf("ERROR: For %s expected %s, got %s", testCase.game, testCase.expected, answer)
+		}
+	}
+}
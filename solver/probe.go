@@ -0,0 +1,118 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/erikbryant/magnets/common"
+	"github.com/erikbryant/magnets/magnets"
+)
+
+// Probing is the solver's last resort, used only once the other rules stop
+// making progress. For each undecided domino it tries each sign that is still
+// possible on a copy of the game, runs the other rules on the copy and, if
+// they reach a contradiction, removes that sign from the real CBS. It only
+// looks one assumption deep.
+
+// clone returns a copy of the CBS that does not share storage with it.
+func (cbs *CBS) clone() *CBS {
+	c := &CBS{cells: make([][]map[rune]bool, len(cbs.cells))}
+	for row := range cbs.cells {
+		c.cells[row] = make([]map[rune]bool, len(cbs.cells[row]))
+		for col := range cbs.cells[row] {
+			c.cells[row][col] = make(map[rune]bool, len(cbs.cells[row][col]))
+			for r, ok := range cbs.cells[row][col] {
+				c.cells[row][col][r] = ok
+			}
+		}
+	}
+	return c
+}
+
+// consistent returns an error if the CBS and the guess break the rules of the
+// game, including the counts.
+func (cbs *CBS) consistent(game magnets.Game) error {
+	err := cbs.validate(game)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range []rune{common.Positive, common.Negative} {
+		for row := 0; row < game.Guess.Height(); row++ {
+			need := game.CountRow(row, r)
+			if need < 0 {
+				continue
+			}
+			if game.Guess.CountRow(row, r) > need || cbs.rowHasSpaceForTotal(game, row, r) < need {
+				return fmt.Errorf("row %d cannot have %d '%c'", row, need, r)
+			}
+		}
+		for col := 0; col < game.Guess.Width(); col++ {
+			need := game.CountCol(col, r)
+			if need < 0 {
+				continue
+			}
+			if game.Guess.CountCol(col, r) > need || cbs.colHasSpaceForTotal(game, col, r) < need {
+				return fmt.Errorf("col %d cannot have %d '%c'", col, need, r)
+			}
+		}
+	}
+
+	return nil
+}
+
+// contradicts returns true if setting the domino at row, col to r leads the
+// other rules to a contradiction. It works on copies of the CBS and the game.
+// Panics other than the rules' own contradictions are passed on.
+func (cbs *CBS) contradicts(ctx context.Context, game magnets.Game, row, col int, r rune) (contradiction bool) {
+	g := game.Clone()
+	c := cbs.clone()
+
+	defer func() {
+		if p := recover(); p != nil {
+			if _, ok := p.(contradictionError); !ok {
+				panic(p)
+			}
+			contradiction = true
+		}
+	}()
+
+	c.setFrame(g, row, col, r)
+	for ctx.Err() == nil {
+		c.dirty = false
+		for _, rule := range c.rules() {
+			rule.rule(g)
+			if c.consistent(g) != nil {
+				return true
+			}
+		}
+		if !c.dirty {
+			break
+		}
+	}
+
+	return false
+}
+
+// probe tries each possible sign of each undecided domino, and removes the
+// first one it finds that leads to a contradiction.
+func (cbs *CBS) probe(ctx context.Context, game magnets.Game) {
+	for frame := range game.Frames() {
+		row, col := frame.Unpack()
+		if cbs.decided(row, col) {
+			continue
+		}
+		for _, r := range []rune{common.Positive, common.Negative, common.Neutral} {
+			if ctx.Err() != nil {
+				return
+			}
+			if !cbs.possibility(row, col, r) {
+				continue
+			}
+			if cbs.contradicts(ctx, game, row, col, r) {
+				cbs.unsetPossibility(game, row, col, r)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package solver
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/erikbryant/magnets/common"
+	"github.com/erikbryant/magnets/magnets"
+)
+
+func TestClone(t *testing.T) {
+	game, _ := magnets.Deserialize("3x3:201,102,120,111,LRTT*BBLR")
+	cbs := new(game)
+	c := cbs.clone()
+
+	c.unsetPossibility(game, 0, 0, common.Neutral)
+	if !cbs.possibility(0, 0, common.Neutral) {
+		t.Errorf("ERROR: Changing the clone changed the original")
+	}
+}
+
+func TestContradicts(t *testing.T) {
+	// +-#
+	// -*#
+	// +-+
+	game, ok := magnets.Deserialize("3x3:201,102,120,111,LRTT*BBLR")
+	if !ok {
+		t.Fatalf("Unable to deserialize board")
+	}
+	cbs := new(game)
+
+	testCases := []struct {
+		row, col int
+		r        rune
+		expected bool
+	}{
+		{0, 0, common.Positive, false},
+		// Col 1 has no +.
+		{0, 0, common.Negative, true},
+		// Col 0 needs the + at its top.
+		{0, 0, common.Neutral, true},
+		{0, 2, common.Neutral, false},
+	}
+
+	for _, testCase := range testCases {
+		answer := cbs.contradicts(context.Background(), game, testCase.row, testCase.col, testCase.r)
+		if answer != testCase.expected {
+			t.Errorf("ERROR: For '%c' at %d, %d expected %t got %t", testCase.r, testCase.row, testCase.col, testCase.expected, answer)
+		}
+	}
+
+	// A panic that is not a contradiction, here an index out of range, is
+	// a bug and is passed on.
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("ERROR: Expected a panic for a cell off the board")
+			}
+		}()
+		cbs.contradicts(context.Background(), game, 5, 5, common.Positive)
+	}()
+
+	// Probing leaves the game alone.
+	for cell := range game.Guess.Cells() {
+		row, col := cell.Unpack()
+		if r := game.Guess.Get(row, col, false); r != common.Empty && r != common.Wall {
+			t.Errorf("ERROR: Expected %d, %d to be empty got '%c'", row, col, r)
+		}
+	}
+}
+
+func TestProbe(t *testing.T) {
+	// The other rules stall on this game.
+	game, ok := magnets.Deserialize("6x2:111110,32,111101,32,TTTTLRBBBBLR")
+	if !ok {
+		t.Fatalf("Unable to deserialize board")
+	}
+
+	var stats Stats
+	err := solve(context.Background(), game, &stats)
+	if err != nil {
+		t.Errorf("ERROR: Unexpected error %v", err)
+	}
+	if !game.Solved() {
+		t.Errorf("ERROR: Expected the game to be solved")
+	}
+	if !slices.Contains(stats.Trace, "probe") {
+		t.Errorf("ERROR: Expected probe in the trace, got %v", stats.Trace)
+	}
+}
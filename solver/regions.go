@@ -0,0 +1,23 @@
+package solver
+
+import (
+	"github.com/erikbryant/magnets/board"
+	"github.com/erikbryant/magnets/common"
+	"github.com/erikbryant/magnets/magnets"
+)
+
+// magnetRegions returns the connected regions of cells that the CBS knows
+// hold magnets, i.e., that can no longer be neutral. Neighboring magnets
+// always have opposite signs, so the sign of any one cell in a region
+// decides the signs of all of the others.
+func (cbs *CBS) magnetRegions(game magnets.Game) []board.Region {
+	known := board.New(game.Guess.Width(), game.Guess.Height())
+	for cell := range known.Cells() {
+		row, col := cell.Unpack()
+		if !cbs.possibility(row, col, common.Neutral) && !cbs.possibility(row, col, common.Wall) {
+			known.Set(row, col, common.Marker, false)
+		}
+	}
+
+	return known.Components(common.Marker)
+}
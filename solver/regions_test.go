@@ -0,0 +1,51 @@
+package solver
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/erikbryant/magnets/board"
+	"github.com/erikbryant/magnets/common"
+	"github.com/erikbryant/magnets/magnets"
+)
+
+func TestMagnetRegions(t *testing.T) {
+	game, ok := magnets.Deserialize("3x3:201,102,120,111,LRTT*BBLR")
+	if !ok {
+		t.Fatalf("Unable to deserialize board")
+	}
+
+	cbs := new(game)
+
+	// At the start nothing is known to be a magnet.
+	answer := cbs.magnetRegions(game)
+	if len(answer) != 0 {
+		t.Errorf("ERROR: Expected no regions got %v", answer)
+	}
+
+	// LRT
+	// T*B
+	// BLR
+	cbs.unsetPossibility(game, 0, 0, common.Neutral)
+	cbs.unsetPossibility(game, 2, 1, common.Neutral)
+
+	expected := []board.Region{
+		{{Row: 0, Col: 0}, {Row: 0, Col: 1}},
+		{{Row: 2, Col: 1}, {Row: 2, Col: 2}},
+	}
+	answer = cbs.magnetRegions(game)
+	if !slices.EqualFunc(answer, expected, slices.Equal) {
+		t.Errorf("ERROR: Expected %v got %v", expected, answer)
+	}
+
+	// The vertical domino on the left joins them.
+	cbs.unsetPossibility(game, 1, 0, common.Neutral)
+
+	expected = []board.Region{
+		{{Row: 0, Col: 0}, {Row: 0, Col: 1}, {Row: 1, Col: 0}, {Row: 2, Col: 0}, {Row: 2, Col: 1}, {Row: 2, Col: 2}},
+	}
+	answer = cbs.magnetRegions(game)
+	if !slices.EqualFunc(answer, expected, slices.Equal) {
+		t.Errorf("ERROR: Expected %v got %v", expected, answer)
+	}
+}
@@ -0,0 +1,193 @@
+package solver
+
+// This file defines a common shape for the different ways of solving a game,
+// and a registry so callers can pick one by name.
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/erikbryant/magnets/board"
+	"github.com/erikbryant/magnets/magnets"
+)
+
+// Status is the outcome of an attempt to solve a game.
+type Status int
+
+const (
+	// Solved means the solver found the solution.
+	Solved Status = iota
+	// Stuck means the solver ran out of ideas before finding a solution.
+	Stuck
+	// Contradiction means the solver proved the game has no solution.
+	Contradiction
+)
+
+// String returns the name of the status.
+func (s Status) String() string {
+	switch s {
+	case Solved:
+		return "solved"
+	case Stuck:
+		return "stuck"
+	case Contradiction:
+		return "contradiction"
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// Stats holds the statistics from an attempt to solve a game.
+type Stats struct {
+	Elapsed time.Duration
+	// Steps is the number of passes, or search nodes, the solver used.
+	Steps int
+	// Trace is the names of the rules that made progress, in order.
+	Trace []string
+}
+
+// Result is the outcome of an attempt to solve a game.
+type Result struct {
+	Status Status
+	// Solution is the solver's final guess. It is only complete if the
+	// status is Solved.
+	Solution board.Board
+	Stats    Stats
+}
+
+// Solver is a strategy for solving a game.
+type Solver interface {
+	// Name returns the name the solver is registered under.
+	Name() string
+	// Solve attempts to solve the game. It does not modify the game. It
+	// returns the context's error if the context is cancelled first.
+	Solve(ctx context.Context, game magnets.Game) (Result, error)
+}
+
+var (
+	registry = map[string]Solver{}
+)
+
+// Register makes a solver available by name. It panics if the name is
+// already taken.
+func Register(s Solver) {
+	if _, ok := registry[s.Name()]; ok {
+		panic(fmt.Sprintf("solver %s registered twice", s.Name()))
+	}
+	registry[s.Name()] = s
+}
+
+// Lookup returns the solver registered under the given name.
+func Lookup(name string) (Solver, bool) {
+	s, ok := registry[name]
+	return s, ok
+}
+
+// Names returns the names of all registered solvers, sorted.
+func Names() []string {
+	var names []string
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// All returns every registered solver, sorted by name.
+func All() []Solver {
+	var solvers []Solver
+	for _, name := range Names() {
+		solvers = append(solvers, registry[name])
+	}
+	return solvers
+}
+
+// init registers the built-in solvers.
+func init() {
+	Register(cbsSolver{})
+	Register(enumerator{name: "brute", enumerate: (*magnets.Game).BacktrackSolutions})
+	Register(enumerator{name: "dlx", enumerate: (*magnets.Game).EnumerateSolutions})
+}
+
+// cbsSolver is the constraint-based solver.
+type cbsSolver struct{}
+
+// Name returns the name of the solver.
+func (cbsSolver) Name() string {
+	return "cbs"
+}
+
+// Solve runs the constraint-based solver on a copy of the guess board. Panics
+// other than the CBS's own contradictions are passed on.
+func (cbsSolver) Solve(ctx context.Context, game magnets.Game) (result Result, err error) {
+	start := time.Now()
+	game.Guess = game.Guess.Clone()
+
+	defer func() {
+		result.Solution = game.Guess
+		result.Stats.Elapsed = time.Since(start)
+		if p := recover(); p != nil {
+			if _, ok := p.(contradictionError); !ok {
+				panic(p)
+			}
+			result.Status = Contradiction
+			return
+		}
+		if game.Solved() {
+			result.Status = Solved
+		} else {
+			result.Status = Stuck
+		}
+	}()
+
+	err = solve(ctx, game, &result.Stats)
+
+	return
+}
+
+// enumerator is a solver that lists every solution to the game.
+type enumerator struct {
+	name      string
+	enumerate func(*magnets.Game, context.Context, func(board.Board) bool) (int, error)
+}
+
+// Name returns the name of the solver.
+func (e enumerator) Name() string {
+	return e.name
+}
+
+// Solve looks for up to two solutions. If there is exactly one, that is the
+// answer. If there are more, the solver cannot choose between them.
+func (e enumerator) Solve(ctx context.Context, game magnets.Game) (Result, error) {
+	start := time.Now()
+	game.Guess = game.Guess.Clone()
+
+	var result Result
+	result.Solution = game.Guess.Clone()
+
+	seen := 0
+	found, err := e.enumerate(&game, ctx, func(solution board.Board) bool {
+		seen++
+		if seen == 1 {
+			result.Solution = solution
+		}
+		return seen < 2
+	})
+
+	switch {
+	case err != nil:
+		result.Status = Stuck
+		result.Solution = game.Guess.Clone()
+	case found == 0:
+		result.Status = Contradiction
+	case found == 1:
+		result.Status = Solved
+	default:
+		result.Status = Stuck
+		result.Solution = game.Guess.Clone()
+	}
+	result.Stats.Elapsed = time.Since(start)
+
+	return result, err
+}
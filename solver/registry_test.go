@@ -0,0 +1,134 @@
+package solver
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/erikbryant/magnets/common"
+	"github.com/erikbryant/magnets/magnets"
+)
+
+func TestNames(t *testing.T) {
+	expected := []string{"brute", "cbs", "dlx"}
+
+	answer := Names()
+	if len(answer) != len(expected) {
+		t.Fatalf("ERROR: Expected %v, got %v", expected, answer)
+	}
+	for i := range expected {
+		if answer[i] != expected[i] {
+			t.Errorf("ERROR: Expected %v, got %v", expected, answer)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	s, ok := Lookup("cbs")
+	if !ok || s.Name() != "cbs" {
+		t.Errorf("ERROR: Expected to find cbs")
+	}
+
+	_, ok = Lookup("nope")
+	if ok {
+		t.Errorf("ERROR: Did not expect to find nope")
+	}
+}
+
+func TestSolverSolve(t *testing.T) {
+	testCases := []struct {
+		game     string
+		expected map[string]Status
+	}{
+		{"1x2:1,10,1,01,TB", map[string]Status{"cbs": Solved, "brute": Solved, "dlx": Solved}},
+		{"2x2:11,00,11,00,TTBB", map[string]Status{"brute": Contradiction, "dlx": Contradiction}},
+		{"2x2:11,11,11,11,TTBB", map[string]Status{"cbs": Stuck, "brute": Stuck, "dlx": Stuck}},
+		{"3x4:212,1202,122,2111,TTTBBBLRTLRB", map[string]Status{"cbs": Solved, "brute": Solved, "dlx": Solved}},
+	}
+
+	for _, testCase := range testCases {
+		for name, expected := range testCase.expected {
+			game, ok := magnets.Deserialize(testCase.game)
+			if !ok {
+				t.Fatalf("Unable to deserialize board %s", testCase.game)
+			}
+
+			s, _ := Lookup(name)
+			result, _ := s.Solve(context.Background(), game)
+			if result.Status != expected {
+				t.Errorf("ERROR: %s on %s expected %s, got %s", name, testCase.game, expected, result.Status)
+			}
+
+			// The caller's game is left alone.
+			if game.Guess.Get(0, 0, false) != common.Empty {
+				t.Errorf("ERROR: %s on %s modified the game", name, testCase.game)
+			}
+
+			if expected == Solved {
+				game.Guess = result.Solution
+				if !game.Solved() {
+					t.Errorf("ERROR: %s on %s returned a bad solution", name, testCase.game)
+				}
+			}
+		}
+	}
+}
+
+func TestSolverSolveContradiction(t *testing.T) {
+	// The rules run before the contradiction are kept in the trace.
+	serial := "10x2:0011110211,34,0011111011,34,LRLRLRLRLRLRLRLRLRLR"
+	game, ok := magnets.Deserialize(serial)
+	if !ok {
+		t.Fatalf("Unable to deserialize board %s", serial)
+	}
+
+	cbs, _ := Lookup("cbs")
+	result, _ := cbs.Solve(context.Background(), game)
+	if result.Status != Contradiction {
+		t.Errorf("ERROR: For %s expected %s, got %s", serial, Contradiction, result.Status)
+	}
+	if len(result.Stats.Trace) == 0 {
+		t.Errorf("ERROR: For %s expected a trace", serial)
+	}
+}
+
+func TestSolverSolveConcurrent(t *testing.T) {
+	// Each solve has its own state, so solves running at the same time
+	// must get the same results as solves run one at a time. Run with
+	// -race to check.
+	serials := []string{
+		"1x2:1,10,1,01,TB",
+		"3x4:212,1202,122,2111,TTTBBBLRTLRB",
+		"5x5:.2..1,3..1.,.2..2,2..2.,LRLRTTLRTBBT*BTTBLRBBLRLR",
+		"6x2:111110,32,111101,32,TTTTLRBBBBLR",
+		"2x2:11,11,11,11,TTBB",
+	}
+	cbs, _ := Lookup("cbs")
+
+	var expected []Result
+	for _, serial := range serials {
+		game, ok := magnets.Deserialize(serial)
+		if !ok {
+			t.Fatalf("Unable to deserialize board %s", serial)
+		}
+		result, _ := cbs.Solve(context.Background(), game)
+		expected = append(expected, result)
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i, serial := range serials {
+				game, _ := magnets.Deserialize(serial)
+				result, _ := cbs.Solve(context.Background(), game)
+				if result.Status != expected[i].Status || !slices.Equal(result.Stats.Trace, expected[i].Stats.Trace) {
+					t.Errorf("ERROR: For %s expected %s %v, got %s %v", serial, expected[i].Status, expected[i].Stats.Trace, result.Status, result.Stats.Trace)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -1,6 +1,7 @@
 package solver
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/erikbryant/magnets/board"
@@ -10,11 +11,11 @@ import (
 
 // justOne iterates through all empty cells. For any that have just one
 // possibility left in the cbs, it sets that frame.
-func (cbs CBS) justOne(game magnets.Game) {
+func (cbs *CBS) justOne(game magnets.Game) {
 	for cell := range game.Guess.Cells(common.Empty) {
 		row, col := cell.Unpack()
 
-		if len(cbs[row][col]) == 1 {
+		if len(cbs.cells[row][col]) == 1 {
 			cbs.setFrame(game, row, col, cbs.getOnlyPossibility(row, col))
 		}
 	}
@@ -22,23 +23,23 @@ func (cbs CBS) justOne(game magnets.Game) {
 
 // satisfied looks at each row/col to see if there are exactly as many spaces
 // to put a given polarity as there are needed.
-func (cbs CBS) satisfied(game magnets.Game) {
+func (cbs *CBS) satisfied(game magnets.Game) {
 	for _, category := range []rune{common.Positive, common.Negative, common.Neutral} {
 		// Row is satisfied in this category? Set those frames. Clear this
 		// possibility elsewhere.
 		for row := 0; row < game.Guess.Height(); row++ {
 			if rowNeeds(game, row, category) == cbs.rowHasSpaceForTotal(game, row, category) {
 				for col := 0; col < game.Guess.Width(); col++ {
-					if cbs[row][col][category] {
+					if cbs.cells[row][col][category] {
 						if category == common.Neutral {
 							cbs.setFrame(game, row, col, category)
 						} else {
 							direction := game.GetFrame(row, col)
 							switch direction {
 							case common.Up:
-								cbs[row][col] = map[rune]bool{category: true}
+								cbs.cells[row][col] = map[rune]bool{category: true}
 							case common.Down:
-								cbs[row][col] = map[rune]bool{category: true}
+								cbs.cells[row][col] = map[rune]bool{category: true}
 							case common.Left:
 								cbs.unsetPossibility(game, row, col, common.Neutral)
 							case common.Right:
@@ -55,16 +56,16 @@ func (cbs CBS) satisfied(game magnets.Game) {
 		for col := 0; col < game.Guess.Width(); col++ {
 			if colNeeds(game, col, category) == cbs.colHasSpaceForTotal(game, col, category) {
 				for row := 0; row < game.Guess.Height(); row++ {
-					if cbs[row][col][category] {
+					if cbs.cells[row][col][category] {
 						if category == common.Neutral {
 							cbs.setFrame(game, row, col, category)
 						} else {
 							direction := game.GetFrame(row, col)
 							switch direction {
 							case common.Up:
-								cbs[row][col] = map[rune]bool{category: true}
+								cbs.cells[row][col] = map[rune]bool{category: true}
 							case common.Down:
-								cbs[row][col] = map[rune]bool{category: true}
+								cbs.cells[row][col] = map[rune]bool{category: true}
 							case common.Left:
 								cbs.unsetPossibility(game, row, col, common.Neutral)
 							case common.Right:
@@ -81,7 +82,7 @@ func (cbs CBS) satisfied(game magnets.Game) {
 // needAll checks to see if the number of pos+neg needed is equal to the number
 // of frames that are still undecided. If so, none of those frames can be neutral.
 // NOTE: Once doubleSingle() is written this function will no longer be needed.
-func (cbs CBS) needAll(game magnets.Game) {
+func (cbs *CBS) needAll(game magnets.Game) {
 	// If there are any that we know what they must be, but have not set them
 	// yet, do that now. Otherwise, the count will be off.
 	cbs.justOne(game)
@@ -117,7 +118,7 @@ func (cbs CBS) needAll(game magnets.Game) {
 
 // oddRowAllMagnets checks to see if the entire row is full of magnets. If it
 // is, and if the row length is odd, then we know the pattern of the magnets.
-func (cbs CBS) oddRowAllMagnets(game magnets.Game) {
+func (cbs *CBS) oddRowAllMagnets(game magnets.Game) {
 	// If the length is not odd then there is nothing we can determine.
 	if game.Guess.Width()%2 == 0 {
 		return
@@ -142,14 +143,14 @@ func (cbs CBS) oddRowAllMagnets(game magnets.Game) {
 				cbs.unsetPossibility(game, row, col, common.Neutral)
 			}
 
-			dirty = true
+			cbs.dirty = true
 		}
 	}
 }
 
 // oddColAllMagnets checks to see if the entire col is full of magnets. If it
 // is, and if the col length is odd, then we know the pattern of the magnets.
-func (cbs CBS) oddColAllMagnets(game magnets.Game) {
+func (cbs *CBS) oddColAllMagnets(game magnets.Game) {
 	// If the length is not odd then there is nothing we can determine.
 	if game.Guess.Height()%2 == 0 {
 		return
@@ -174,7 +175,7 @@ func (cbs CBS) oddColAllMagnets(game magnets.Game) {
 				cbs.unsetPossibility(game, row, col, common.Neutral)
 			}
 
-			dirty = true
+			cbs.dirty = true
 		}
 	}
 }
@@ -184,7 +185,7 @@ func (cbs CBS) oddColAllMagnets(game magnets.Game) {
 // For instance if we need 2 polarities (1 plus and 1 minus) and there is
 // 1 horizontal and 1 vertical frame we know the vertical frame cannot have
 // a polarity.
-func (cbs CBS) doubleSingle(game magnets.Game) {
+func (cbs *CBS) doubleSingle(game magnets.Game) {
 
 	// Enumerate each of the combinations of frames (that are undecided) in the
 	// row/col that will satisfy the pos+neg count conditions. If there is a
@@ -206,7 +207,7 @@ func (cbs CBS) doubleSingle(game magnets.Game) {
 
 // resolveNeighbors() propagates any constraint a cell has (like it can only be
 // negative) to its neighbor (which can then only be positive).
-func (cbs CBS) resolveNeighbors(game magnets.Game) {
+func (cbs *CBS) resolveNeighbors(game magnets.Game) {
 	// If a cell borders one whose polarity is already identified, update the cbs.
 	for cell := range game.Guess.Cells() {
 		row, col := cell.Unpack()
@@ -228,9 +229,147 @@ func (cbs CBS) resolveNeighbors(game magnets.Game) {
 	}
 }
 
+// parity propagates polarity along chains of magnets. Neighboring magnets
+// always have opposite signs, so within a region of cells that are known to
+// be magnets the signs alternate like a checkerboard. If any cell in the
+// region rules out a sign, that rules out the matching sign in every other
+// cell. This generalizes oddRowAllMagnets and oddColAllMagnets.
+func (cbs *CBS) parity(game magnets.Game) {
+	for _, region := range cbs.magnetRegions(game) {
+		// sign is the sign the cell would have if the cells of parity 0
+		// were s.
+		sign := func(cell board.Coord, s rune) rune {
+			if cell.Parity() == 1 {
+				return common.Negate(s)
+			}
+			return s
+		}
+
+		var possible []rune
+		for _, s := range []rune{common.Positive, common.Negative} {
+			ok := true
+			for _, cell := range region {
+				row, col := cell.Unpack()
+				if !cbs.possibility(row, col, sign(cell, s)) {
+					ok = false
+					break
+				}
+			}
+			if ok {
+				possible = append(possible, s)
+			}
+		}
+
+		switch len(possible) {
+		case 0:
+			panic(contradictionError{fmt.Sprintf("No signs fit the region of magnets at %v", region[0])})
+		case 1:
+			for _, cell := range region {
+				row, col := cell.Unpack()
+				if !cbs.decided(row, col) {
+					cbs.unsetPossibility(game, row, col, common.Negate(sign(cell, possible[0])))
+				}
+			}
+		}
+	}
+}
+
+// difference uses the difference between the + and - counts of a line. A
+// domino lying along the line adds one of each, or none, so the difference
+// comes entirely from the crossing dominoes, which have just one cell in the
+// line. If the crossing cells that are still undecided must all take the
+// same sign to make up the difference, they are set to it. Those that
+// cannot take that sign cannot take the other one either, so they are
+// neutral.
+func (cbs *CBS) difference(game magnets.Game, crossing []board.Coord, diff int) {
+	var undecided []board.Coord
+	for _, cell := range crossing {
+		row, col := cell.Unpack()
+		if !cbs.decided(row, col) {
+			undecided = append(undecided, cell)
+			continue
+		}
+		switch cbs.getOnlyPossibility(row, col) {
+		case common.Positive:
+			diff--
+		case common.Negative:
+			diff++
+		}
+	}
+	if diff == 0 {
+		return
+	}
+
+	sign := common.Positive
+	if diff < 0 {
+		sign = common.Negative
+		diff = -diff
+	}
+
+	var candidates []board.Coord
+	for _, cell := range undecided {
+		row, col := cell.Unpack()
+		if cbs.possibility(row, col, sign) {
+			candidates = append(candidates, cell)
+		}
+	}
+	if len(candidates) < diff {
+		panic(contradictionError{fmt.Sprintf("Only %d crossing cells can be '%c', need %d", len(candidates), sign, diff)})
+	}
+	if len(candidates) > diff {
+		return
+	}
+
+	for _, cell := range undecided {
+		row, col := cell.Unpack()
+		cbs.unsetPossibility(game, row, col, common.Negate(sign))
+		if cbs.possibility(row, col, sign) {
+			cbs.unsetPossibility(game, row, col, common.Neutral)
+		}
+	}
+}
+
+// rowDifference applies difference to each row whose counts are known. The
+// crossing dominoes are the vertical ones.
+func (cbs *CBS) rowDifference(game magnets.Game) {
+	for row := 0; row < game.Guess.Height(); row++ {
+		pos, neg := game.CountRow(row, common.Positive), game.CountRow(row, common.Negative)
+		if pos < 0 || neg < 0 {
+			continue
+		}
+		var crossing []board.Coord
+		for col := 0; col < game.Guess.Width(); col++ {
+			switch game.GetFrame(row, col) {
+			case common.Up, common.Down:
+				crossing = append(crossing, board.Coord{Row: row, Col: col})
+			}
+		}
+		cbs.difference(game, crossing, pos-neg)
+	}
+}
+
+// colDifference applies difference to each col whose counts are known. The
+// crossing dominoes are the horizontal ones.
+func (cbs *CBS) colDifference(game magnets.Game) {
+	for col := 0; col < game.Guess.Width(); col++ {
+		pos, neg := game.CountCol(col, common.Positive), game.CountCol(col, common.Negative)
+		if pos < 0 || neg < 0 {
+			continue
+		}
+		var crossing []board.Coord
+		for row := 0; row < game.Guess.Height(); row++ {
+			switch game.GetFrame(row, col) {
+			case common.Left, common.Right:
+				crossing = append(crossing, board.Coord{Row: row, Col: col})
+			}
+		}
+		cbs.difference(game, crossing, pos-neg)
+	}
+}
+
 // zeroInRow looks for rows that have no positives or that have no negatives
 // and removes those possibilities from the cbs.
-func (cbs CBS) zeroInRow(game magnets.Game) {
+func (cbs *CBS) zeroInRow(game magnets.Game) {
 	for _, category := range []rune{common.Positive, common.Negative} {
 		for row := 0; row < game.Guess.Height(); row++ {
 			if game.CountRow(row, category) == 0 {
@@ -243,7 +382,7 @@ func (cbs CBS) zeroInRow(game magnets.Game) {
 
 // zeroInCol looks for columns that have no positives or that have no negatives
 // and removes those possibilities from the cbs.
-func (cbs CBS) zeroInCol(game magnets.Game) {
+func (cbs *CBS) zeroInCol(game magnets.Game) {
 	for _, category := range []rune{common.Positive, common.Negative} {
 		for col := 0; col < game.Guess.Width(); col++ {
 			if game.CountCol(col, category) == 0 {
@@ -254,63 +393,106 @@ func (cbs CBS) zeroInCol(game magnets.Game) {
 	}
 }
 
-func (cbs CBS) checker(game magnets.Game, msg string) {
+func (cbs *CBS) checker(game magnets.Game, msg string) {
 	// fmt.Printf("\n\n\n")
 	// fmt.Println("----> State coming out of", msg, "<-----")
 	// game.Print()
 	// cbs.print()
 	err := cbs.validate(game)
 	if err != nil {
-		game.Print()
-		cbs.print()
-		panic(err)
+		panic(contradictionError{fmt.Sprintf("after %s: %s", msg, err)})
 	}
 }
 
-// Solve attempts to find a solution for the game, or gives up if it cannot.
-func Solve(game magnets.Game) {
-	cbs := new(game)
+// apply runs a single rule, validates the result, and records the rule in the
+// trace if it made progress.
+func (cbs *CBS) apply(game magnets.Game, name string, rule func(magnets.Game), trace *[]string) {
+	wasDirty := cbs.dirty
+	cbs.dirty = false
+
+	rule(game)
+	if cbs.dirty {
+		*trace = append(*trace, name)
+	}
+	cbs.checker(game, name)
 
-	cbs.zeroInRow(game)
-	cbs.checker(game, "zeroInRow")
+	cbs.dirty = cbs.dirty || wasDirty
+}
 
-	cbs.zeroInCol(game)
-	cbs.checker(game, "zeroInCol")
+// rule is a named CBS rule.
+type rule struct {
+	name string
+	rule func(magnets.Game)
+}
+
+// rules returns the rules that solve runs, in order, until they stop making
+// progress.
+func (cbs *CBS) rules() []rule {
+	return []rule{
+		// {"satisfied", cbs.satisfied}, // This is definitely buggy
+		{"resolveNeighbors", cbs.resolveNeighbors},
+		{"parity", cbs.parity},
+		{"rowDifference", cbs.rowDifference},
+		{"colDifference", cbs.colDifference},
+		{"doubleSingle", cbs.doubleSingle},
+		// {"needAll", cbs.needAll}, // This appears to be buggy
+		{"justOne", cbs.justOne},
+	}
+}
 
-	cbs.oddRowAllMagnets(game)
-	cbs.checker(game, "oddRowAllMagnets")
+// solve runs the rules until they stop making progress or the context is
+// cancelled. When they stop, it probes (see probe.go) and, if that finds
+// anything, goes back to the rules. It records in stats the names of the rules
+// that made progress, in the order they did so, and the number of passes it
+// took. They are recorded as it goes, so they are there even if it panics.
+func solve(ctx context.Context, game magnets.Game, stats *Stats) error {
+	cbs := new(game)
 
-	cbs.oddColAllMagnets(game)
-	cbs.checker(game, "oddColAllMagnets")
+	cbs.apply(game, "zeroInRow", cbs.zeroInRow, &stats.Trace)
+	cbs.apply(game, "zeroInCol", cbs.zeroInCol, &stats.Trace)
+	cbs.apply(game, "oddRowAllMagnets", cbs.oddRowAllMagnets, &stats.Trace)
+	cbs.apply(game, "oddColAllMagnets", cbs.oddColAllMagnets, &stats.Trace)
 
-	attempts := 0
 	for {
-		dirty = false
-
-		// cbs.satisfied(game) // This is definitely buggy
-		cbs.checker(game, "satisfied")
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
-		cbs.resolveNeighbors(game)
-		cbs.checker(game, "resolveNeighbors")
+		cbs.dirty = false
 
-		cbs.doubleSingle(game)
-		cbs.checker(game, "doubleSingle")
+		for _, r := range cbs.rules() {
+			cbs.apply(game, r.name, r.rule, &stats.Trace)
+		}
 
-		// cbs.needAll(game) // This appears to be buggy
-		cbs.checker(game, "needAll")
+		stats.Steps++
 
-		cbs.justOne(game)
-		cbs.checker(game, "justOne")
+		if cbs.dirty {
+			continue
+		}
 
-		if !dirty {
+		// The cheap rules have stalled. Try the expensive one.
+		if game.Solved() {
 			break
 		}
-
-		attempts++
-		if attempts > 500 {
-			fmt.Println("WARNING: unable to solve game after >500 attempts")
-			game.Print()
+		cbs.apply(game, "probe", func(game magnets.Game) { cbs.probe(ctx, game) }, &stats.Trace)
+		if !cbs.dirty {
 			break
 		}
 	}
+
+	return ctx.Err()
+}
+
+// Solve attempts to find a solution for the game, or gives up if it cannot.
+// It fills in the game's Guess; to keep the original, solve a Clone or use
+// one of the registered solvers, which work on their own copy.
+func Solve(game *magnets.Game) {
+	SolveContext(context.Background(), game)
+}
+
+// SolveContext attempts to find a solution for the game, or gives up if it
+// cannot. It fills in the game's Guess, and returns the context's error if the
+// context is cancelled first.
+func SolveContext(ctx context.Context, game *magnets.Game) error {
+	return solve(ctx, *game, &Stats{})
 }
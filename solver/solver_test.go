@@ -2,6 +2,7 @@ package solver
 
 import (
 	"bufio"
+	"context"
 	"os"
 	"strings"
 	"testing"
@@ -18,15 +19,15 @@ func TestJustOne(t *testing.T) {
 
 	cbs := new(game)
 
-	dirty = false
-	cbs[0][0] = map[rune]bool{common.Positive: true}
+	cbs.dirty = false
+	cbs.cells[0][0] = map[rune]bool{common.Positive: true}
 	cbs.justOne(game)
 	answer := game.Guess.Get(0, 0, false)
 	if answer != common.Positive {
 		t.Errorf("ERROR: Expected %c, got %c", common.Positive, answer)
 	}
-	if !dirty {
-		t.Errorf("ERROR: Expected dirty = true, got dirty = %v", dirty)
+	if !cbs.dirty {
+		t.Errorf("ERROR: Expected dirty = true, got dirty = %v", cbs.dirty)
 	}
 
 	// This is the other end of that frame, so it should already be set.
@@ -36,15 +37,15 @@ func TestJustOne(t *testing.T) {
 	}
 
 	// And, setting it again should not change it.
-	dirty = false
-	cbs[1][0] = map[rune]bool{common.Negative: true}
+	cbs.dirty = false
+	cbs.cells[1][0] = map[rune]bool{common.Negative: true}
 	cbs.justOne(game)
 	answer = game.Guess.Get(1, 0, false)
 	if answer != common.Negative {
 		t.Errorf("ERROR: Expected %c, got %c", common.Negative, answer)
 	}
-	if dirty {
-		t.Errorf("ERROR: Expected dirty = false, got dirty = %v", dirty)
+	if cbs.dirty {
+		t.Errorf("ERROR: Expected dirty = false, got dirty = %v", cbs.dirty)
 	}
 }
 
@@ -75,10 +76,10 @@ func TestZeroInRow(t *testing.T) {
 
 	for col := 0; col < game.Guess.Width(); col++ {
 		for row := 0; row < game.Guess.Height(); row++ {
-			if cbs[row][col][common.Negative] {
+			if cbs.cells[row][col][common.Negative] {
 				t.Errorf("Unexpected negative at %dx%d", row, col)
 			}
-			if cbs[row][col][common.Positive] {
+			if cbs.cells[row][col][common.Positive] {
 				t.Errorf("Unexpected positive at %dx%d", row, col)
 			}
 		}
@@ -96,10 +97,10 @@ func TestZeroInCol(t *testing.T) {
 
 	for col := 0; col < game.Guess.Width(); col++ {
 		for row := 0; row < game.Guess.Height(); row++ {
-			if cbs[row][col][common.Negative] {
+			if cbs.cells[row][col][common.Negative] {
 				t.Errorf("Unexpected negative at %dx%d", row, col)
 			}
-			if cbs[row][col][common.Positive] {
+			if cbs.cells[row][col][common.Positive] {
 				t.Errorf("Unexpected positive at %dx%d", row, col)
 			}
 		}
@@ -135,7 +136,7 @@ func helper(t *testing.T, file string, expected bool) {
 			continue
 		}
 
-		Solve(game)
+		Solve(&game)
 
 		if game.Solved() != expected {
 			t.Errorf("ERROR: For %s expected solved to be %t", testCase, expected)
@@ -143,8 +144,116 @@ func helper(t *testing.T, file string, expected bool) {
 	}
 }
 
+func TestSolveContext(t *testing.T) {
+	game, ok := magnets.Deserialize("3x4:212,1202,122,2111,TTTBBBLRTLRB")
+	if !ok {
+		t.Errorf("Unable to deserialize board")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := SolveContext(ctx, &game)
+	if err != context.Canceled {
+		t.Errorf("ERROR: Expected %v, got %v", context.Canceled, err)
+	}
+
+	game, _ = magnets.Deserialize("3x4:212,1202,122,2111,TTTBBBLRTLRB")
+	err = SolveContext(context.Background(), &game)
+	if err != nil {
+		t.Errorf("ERROR: Unexpected error %v", err)
+	}
+	if !game.Solved() {
+		t.Errorf("ERROR: Expected game to be solved")
+	}
+}
+
 // This is becoming a regression test. If the run time gets too high, move out of the unit tests.
 func TestSolve(t *testing.T) {
 	// helper(t, "testcases_solve.txt", true)
 	// helper(t, "testcases_solve_fail.txt", false)
 }
+
+func TestParity(t *testing.T) {
+	// +-#
+	// -*#
+	// +-+
+	game, ok := magnets.Deserialize("3x3:201,102,120,111,LRTT*BBLR")
+	if !ok {
+		t.Errorf("Unable to deserialize board")
+	}
+
+	cbs := new(game)
+
+	// The three dominoes on the left and bottom are magnets, and the bottom
+	// right corner is not negative.
+	cbs.unsetPossibility(game, 0, 0, common.Neutral)
+	cbs.unsetPossibility(game, 1, 0, common.Neutral)
+	cbs.unsetPossibility(game, 2, 1, common.Neutral)
+	cbs.unsetPossibility(game, 2, 2, common.Negative)
+	cbs.parity(game)
+
+	expected := []string{"+-#", "-*#", "+-+"}
+	for row, line := range expected {
+		for col, r := range line {
+			if r == '#' || r == '*' {
+				continue
+			}
+			if !cbs.decided(row, col) || !cbs.possibility(row, col, r) {
+				t.Errorf("ERROR: Expected %c at %d, %d got %v", r, row, col, cbs.cells[row][col])
+			}
+		}
+	}
+
+	// The right hand domino is untouched.
+	if cbs.decided(0, 2) {
+		t.Errorf("ERROR: Expected 0, 2 to be undecided got %v", cbs.cells[0][2])
+	}
+}
+
+func TestDifference(t *testing.T) {
+	// +-#
+	// -*#
+	// +-+
+	game, ok := magnets.Deserialize("3x3:201,102,120,111,LRTT*BBLR")
+	if !ok {
+		t.Errorf("Unable to deserialize board")
+	}
+
+	// Row 2 has one more + than -, and only one vertical domino.
+	cbs := new(game)
+	cbs.rowDifference(game)
+	for _, c := range []struct {
+		row, col int
+		r        rune
+	}{{2, 0, common.Positive}, {1, 0, common.Negative}} {
+		if !cbs.decided(c.row, c.col) || !cbs.possibility(c.row, c.col, c.r) {
+			t.Errorf("ERROR: Expected %c at %d, %d got %v", c.r, c.row, c.col, cbs.cells[c.row][c.col])
+		}
+	}
+
+	// Col 1 has two more - than +, and only two horizontal dominoes.
+	// Col 0 has one more + than -, and only one.
+	cbs = new(game)
+	cbs.colDifference(game)
+	for _, c := range []struct {
+		row, col int
+		r        rune
+	}{{0, 1, common.Negative}, {2, 1, common.Negative}, {0, 0, common.Positive}, {2, 2, common.Positive}} {
+		if !cbs.decided(c.row, c.col) || !cbs.possibility(c.row, c.col, c.r) {
+			t.Errorf("ERROR: Expected %c at %d, %d got %v", c.r, c.row, c.col, cbs.cells[c.row][c.col])
+		}
+	}
+
+	// Unknown counts tell us nothing.
+	game, _ = magnets.Deserialize("3x3:...,...,...,...,LRTT*BBLR")
+	cbs = new(game)
+	cbs.rowDifference(game)
+	cbs.colDifference(game)
+	for cell := range game.Guess.Cells() {
+		row, col := cell.Unpack()
+		if cbs.decided(row, col) && game.Guess.Get(row, col, false) != common.Wall {
+			t.Errorf("ERROR: Expected %d, %d to be undecided got %v", row, col, cbs.cells[row][col])
+		}
+	}
+}